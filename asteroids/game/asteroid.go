@@ -1,80 +1,160 @@
 package game
 
 const (
-	asteroidSpeed = 100
-	asteroidSpin  = 2
+	asteroidJaggedness = 0.45
+	asteroidSplitSpeed = 60
+	asteroidMinArea    = 40
 )
 
-var asteroidPoints = []float32{
-	-10, 0,
-	-5, 7,
-	-3, 4,
-	1, 10,
-	5, 4,
-	10, 0,
-	5, -6,
-	2, -10,
-	-4, -10,
-	-4, -5,
-	-10, 0,
+type asteroidSize int
+
+const (
+	asteroidLarge asteroidSize = iota
+	asteroidMedium
+	asteroidSmall
+)
+
+// asteroidClasses is the outline radius, number of vertices and score of each
+// size of asteroid.
+var asteroidClasses = []struct {
+	radius   float32
+	vertices int
+	points   int
+}{
+	asteroidLarge:  {radius: 40, vertices: 14, points: 20},
+	asteroidMedium: {radius: 22, vertices: 11, points: 50},
+	asteroidSmall:  {radius: 12, vertices: 8, points: 100},
 }
 
 type Asteroid struct {
 	*Sprite
-	parent bool
+	size asteroidSize
 }
 
-func newAsteroid() *Asteroid {
-	new_asteroid := &Asteroid{
-		parent: true,
+func newAsteroid(game *Game) *Asteroid {
+	// spawn on the left or top edge so that the middle of the screen is clear
+	x, y := game.randMax(game.screenWidth), game.randMax(game.screenHeight)
+	if game.rng.Intn(2) == 0 {
+		x = 0
+	} else {
+		y = 0
 	}
 
-	new_asteroid.Sprite = NewSprite(new_asteroid, "asteroid",
-		randMax(screenWidth),
-		randMax(screenHeight),
-		randRange(3, 8),
-		asteroidPoints, true)
-	new_asteroid.vx = randLimits(asteroidSpeed)
-	new_asteroid.vy = randLimits(asteroidSpeed)
-	new_asteroid.vrot = randLimits(asteroidSpin)
+	class := asteroidClasses[asteroidLarge]
+	outline := newOutline(game.rng, class.radius, asteroidJaggedness, class.vertices)
+	new_asteroid := spawnAsteroid(game, asteroidLarge, x, y, outline)
+	new_asteroid.vx = game.randLimits(game.tuning.AsteroidSpeed) * game.waveSpeed()
+	new_asteroid.vy = game.randLimits(game.tuning.AsteroidSpeed) * game.waveSpeed()
+	new_asteroid.vrot = game.randLimits(game.tuning.AsteroidSpin)
 
 	return new_asteroid
 }
 
+func spawnAsteroid(game *Game, size asteroidSize, x, y float32, outline []float32) *Asteroid {
+	new_asteroid := &Asteroid{size: size}
+	new_asteroid.Sprite = NewSprite(game, new_asteroid, "asteroid", x, y, 1, outline, true)
+	new_asteroid.body.SetFilter(categoryAsteroid, maskAsteroid)
+	new_asteroid.restitution = asteroidRestitution
+	return new_asteroid
+}
+
+func (asteroid *Asteroid) Kind() Kind {
+	return KindAsteroid
+}
+
 func (asteroid *Asteroid) Update(dt float32) {
-	if collisions := asteroid.UpdateMovement(dt); len(collisions) > 0 {
-		for _, c := range collisions {
-			if collisions[0].Name == "ship" {
-				c.Collidable.Destroy(false)
-			} else if collisions[0].Name == "bullet" {
-				score++
-				asteroid.Destroy(false)
-				c.Collidable.Destroy(false)
-			}
+	asteroid.UpdateMovement(dt)
+}
+
+// hit breaks the asteroid along the line through x, y heading in dx, dy, which
+// is the path of whatever hit it. It is only scored if one of the seats hit it.
+func (asteroid *Asteroid) hit(x, y, dx, dy float32, scorer *seat) {
+	if !asteroid.game.entities.Alive(asteroid) {
+		return
+	}
+	if scorer != nil {
+		asteroid.game.addScore(scorer, asteroidClasses[asteroid.size].points*asteroid.game.wellBonus(asteroid.x, asteroid.y))
+		asteroid.game.dropPickup(asteroid.x, asteroid.y, asteroid.vx, asteroid.vy)
+	}
+	if asteroid.size != asteroidSmall {
+		// a hit on the ghost across the edge is moved back onto the outline
+		x = asteroid.x + nearest(x-asteroid.x, asteroid.game.screenWidth)
+		y = asteroid.y + nearest(y-asteroid.y, asteroid.game.screenHeight)
+		asteroid.split(x, y, dx, dy)
+	}
+	asteroid.Destroy(false)
+}
+
+// split cuts the asteroid's outline in two along a line and spawns a piece for
+// each side that is big enough, the rest becomes debris. The pieces get the
+// velocity of the point of the parent they came from, then are pushed apart by
+// equal and opposite impulses so together they keep the parent's momentum.
+// Without a direction, as from a contact with no normal, it splits along the
+// parent's heading, or else the line from the hit to its middle.
+func (asteroid *Asteroid) split(x, y, dx, dy float32) {
+	game := asteroid.game
+	outline := openPolygon(append([]float32{}, asteroid.GetPoints()...))
+	area, cx, cy := polygonArea(outline)
+
+	length := sqrt(dx*dx + dy*dy)
+	if length == 0 {
+		dx, dy = asteroid.vx, asteroid.vy
+		length = sqrt(dx*dx + dy*dy)
+	}
+	if length == 0 {
+		dx, dy = cx-x, cy-y
+		length = sqrt(dx*dx + dy*dy)
+	}
+	if length == 0 {
+		dx, dy, length = 1, 0, 1
+	}
+
+	halves := [][]float32{clipPolygon(outline, x, y, dx, dy), clipPolygon(outline, x, y, -dx, -dy)}
+	if halves[0] == nil || halves[1] == nil {
+		// the line only grazed the outline so split through the middle instead
+		halves = [][]float32{clipPolygon(outline, cx, cy, dx, dy), clipPolygon(outline, cx, cy, -dx, -dy)}
+	}
+
+	nx, ny := -dy/length, dx/length
+	impulse := asteroidSplitSpeed * area / 2
+
+	for _, half := range halves {
+		if half == nil {
+			continue
+		}
+		pieceArea, px, py := polygonArea(half)
+		if pieceArea < asteroidMinArea {
+			newExplosion(game, closePolygon(half))
+			continue
+		}
+
+		local := make([]float32, len(half))
+		for i := 0; i < len(half); i += 2 {
+			local[i], local[i+1] = half[i]-px, half[i+1]-py
 		}
+		side := float32(1)
+		if (px-cx)*nx+(py-cy)*ny < 0 {
+			side = -1
+		}
+
+		piece := spawnAsteroid(game, asteroid.size+1, px, py, closePolygon(local))
+		piece.vx = asteroid.vx - asteroid.vrot*(py-cy) + side*nx*impulse/pieceArea
+		piece.vy = asteroid.vy + asteroid.vrot*(px-cx) + side*ny*impulse/pieceArea
+		piece.vrot = asteroid.vrot
+		game.entities.Spawn(piece)
 	}
 }
 
 func (asteroid *Asteroid) Destroy(force bool) {
-	removeObject(asteroid)
+	game := asteroid.game
+	if !game.entities.Despawn(asteroid) {
+		return
+	}
 	asteroid.Sprite.Destroy()
 	if !force {
-		if asteroid.parent {
-			for i := 0; i < 3; i++ {
-				a := &Asteroid{parent: false}
-				a.Sprite = NewSprite(a, "asteroid",
-					asteroid.x,
-					asteroid.y,
-					randRange(1, 3),
-					asteroidPoints, true)
-				a.vx = randLimits(asteroidSpeed)
-				a.vy = randLimits(asteroidSpeed)
-				a.vrot = randLimits(asteroidSpin)
-				addObject(a)
-			}
-		} else {
-			newExplosion(asteroid.Sprite)
+		if asteroid.size == asteroidSmall {
+			newExplosion(game, asteroid.GetPoints())
 		}
-		bomb.Play()
+		game.emit(event{kind: eventAsteroidDestroyed, size: asteroid.size})
 	}
 }
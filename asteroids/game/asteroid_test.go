@@ -0,0 +1,44 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func finite(values ...float32) bool {
+	for _, value := range values {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSplitWithoutDirection(t *testing.T) {
+	for _, moving := range []bool{true, false} {
+		game := New(800, 600, nil, nil, nil)
+		outline := newOutline(game.rng, asteroidClasses[asteroidLarge].radius, asteroidJaggedness, asteroidClasses[asteroidLarge].vertices)
+		parent := spawnAsteroid(game, asteroidLarge, 400, 300, outline)
+		if moving {
+			parent.vx, parent.vy = 30, -20
+		}
+		game.entities.Spawn(parent)
+		game.entities.flush()
+		before := game.entities.Count(KindAsteroid)
+
+		parent.split(400, 300, 0, 0)
+		game.entities.flush()
+		if pieces := game.entities.Count(KindAsteroid) - before; pieces != 2 {
+			t.Errorf("moving %v: split into %v pieces", moving, pieces)
+		}
+		game.entities.EachKind(KindAsteroid, func(id EntityID, object GameObject) {
+			piece := object.(*Asteroid)
+			if piece == parent {
+				return
+			}
+			if !finite(piece.x, piece.y, piece.vx, piece.vy) {
+				t.Errorf("moving %v: piece at %v,%v heading %v,%v", moving, piece.x, piece.y, piece.vx, piece.vy)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package game
+
+import (
+	"math"
+)
+
+const (
+	autopilotLookAhead = 1.2
+	autopilotMargin    = 14
+	autopilotShieldAt  = 0.35
+	autopilotRange     = 350
+	autopilotAimSlack  = 0.08
+	autopilotCruise    = 150
+	autopilotWellRange = 6
+	attractDelay       = 10
+)
+
+// autopilot flies a seat's ship through the same Controls a human uses. It
+// dodges whatever is about to hit it and otherwise shoots at the closest target.
+type autopilot struct {
+	game  *Game
+	seat  int
+	frame int
+}
+
+func newAutopilot(game *Game, seat int) *autopilot {
+	return &autopilot{game: game, seat: seat}
+}
+
+// player is the ship being flown, or nil if it isn't flying.
+func (pilot *autopilot) player() *Player {
+	if seats := pilot.game.seats; pilot.seat < len(seats) {
+		return seats[pilot.seat].player
+	}
+	return nil
+}
+
+func (pilot *autopilot) Read() Input {
+	game := pilot.game
+	pilot.frame++
+	if game.choosing {
+		// tap fire to launch with whatever ship and mode are showing
+		if pilot.frame%2 == 0 {
+			return InputFire
+		}
+		return 0
+	}
+	player := pilot.player()
+	if player == nil || game.gameOver {
+		return 0
+	}
+
+	if heading, when, ok := pilot.threat(); ok {
+		input := pilot.turnTo(heading)
+		if abs(angleBetween(player.rot, heading)) < 0.5 {
+			input |= InputThrust
+		}
+		if when < autopilotShieldAt {
+			if player.shield > 0 {
+				input |= InputShield
+			} else if player.hyperspace == 0 {
+				input |= InputHyperspace
+			}
+		}
+		return input | InputFire
+	}
+
+	input := Input(0)
+	if aim, distance, ok := pilot.target(); ok {
+		input = pilot.turnTo(aim)
+		if abs(angleBetween(player.rot, aim)) < autopilotAimSlack && distance < autopilotRange {
+			input |= InputFire
+		}
+	}
+	if sqrt(player.vx*player.vx+player.vy*player.vy) < autopilotCruise/4 && pilot.frame%120 < 10 {
+		// drift a little so it doesn't sit still waiting to be hit
+		input |= InputThrust
+	}
+	return input
+}
+
+// threat finds the object that will come closest to hitting the ship soonest
+// and returns the heading that gets out of its way and how long until it hits.
+func (pilot *autopilot) threat() (float32, float32, bool) {
+	game := pilot.game
+	player := pilot.player()
+	// get away from any well that is too close before anything else
+	for _, well := range game.wells {
+		rx, ry := game.offset(player.x, player.y, well.x, well.y)
+		if reach := wellClasses[well.kind].horizon * autopilotWellRange; rx*rx+ry*ry < reach*reach {
+			return atan2(-rx, ry), autopilotLookAhead, true
+		}
+	}
+	found := false
+	var soonest, heading float32
+	check := func(sprite *Sprite) {
+		rx, ry := pilot.offset(sprite)
+		rvx, rvy := sprite.vx-player.vx, sprite.vy-player.vy
+		t := float32(0)
+		if speed := rvx*rvx + rvy*rvy; speed > 0 {
+			t = max(0, -(rx*rvx+ry*rvy)/speed)
+		}
+		if t > autopilotLookAhead || (found && t >= soonest) {
+			return
+		}
+		mx, my := rx+rvx*t, ry+rvy*t
+		minX, minY, maxX, maxY := sprite.body.GetBounds()
+		if sqrt(mx*mx+my*my) > max(maxX-minX, maxY-minY)/2+autopilotMargin {
+			return
+		}
+		// head away from where it will pass, or sideways if it is dead on
+		if mx*mx+my*my < 1 {
+			mx, my = -rvy, rvx
+		}
+		found, soonest, heading = true, t, atan2(-mx, my)
+	}
+	game.entities.Each(func(id EntityID, object GameObject) {
+		switch object := object.(type) {
+		case *Asteroid:
+			check(object.Sprite)
+		case *Saucer:
+			check(object.Sprite)
+		case *Bullet:
+			if object.owner == nil || (game.versus && object.owner != player.seat) {
+				check(object.Sprite)
+			}
+		}
+	})
+	return heading, soonest, found
+}
+
+// target picks the saucer if there is one, or else the closest asteroid or, in
+// a versus round, other ship, and returns the heading to shoot it and how far
+// away it is.
+func (pilot *autopilot) target() (float32, float32, bool) {
+	game := pilot.game
+	player := pilot.player()
+	var best *Sprite
+	bestDistance := float32(math.MaxFloat32)
+	game.entities.Each(func(id EntityID, object GameObject) {
+		var sprite *Sprite
+		switch object := object.(type) {
+		case *Asteroid:
+			sprite = object.Sprite
+		case *Saucer:
+			sprite = object.Sprite
+		case *Player:
+			if !game.versus || object == player {
+				return
+			}
+			sprite = object.Sprite
+		default:
+			return
+		}
+		rx, ry := pilot.offset(sprite)
+		distance := sqrt(rx*rx + ry*ry)
+		if _, saucer := object.(*Saucer); saucer {
+			distance /= 4
+		}
+		if distance < bestDistance {
+			best, bestDistance = sprite, distance
+		}
+	})
+	if best == nil {
+		return 0, 0, false
+	}
+	rx, ry := pilot.offset(best)
+	aim := intercept(player.x, player.y, player.x+rx, player.y+ry, best.vx, best.vy, game.tuning.BulletSpeed)
+	return aim, sqrt(rx*rx + ry*ry), true
+}
+
+// offset is where the sprite is from the ship, going the short way around the
+// wrapped screen.
+func (pilot *autopilot) offset(sprite *Sprite) (float32, float32) {
+	game, player := pilot.game, pilot.player()
+	return nearest(sprite.x-player.x, game.screenWidth), nearest(sprite.y-player.y, game.screenHeight)
+}
+
+func (pilot *autopilot) turnTo(heading float32) Input {
+	player := pilot.player()
+	slack := player.class.TurnRate * timeStep / 2
+	switch diff := angleBetween(player.rot, heading); {
+	case diff > slack:
+		return InputRight
+	case diff < -slack:
+		return InputLeft
+	}
+	return 0
+}
+
+// angleBetween is how far, from -Pi to Pi, the ship has to turn clockwise to go
+// from one heading to the other.
+func angleBetween(from, to float32) float32 {
+	diff := float32(math.Mod(float64(to-from), 2*math.Pi))
+	if diff > math.Pi {
+		diff -= 2 * math.Pi
+	} else if diff < -math.Pi {
+		diff += 2 * math.Pi
+	}
+	return diff
+}
+
+// updateAttract starts a demo round flown by the autopilot once the title has
+// sat without any input for attractDelay seconds, returning true if it did.
+func (game *Game) updateAttract() bool {
+	title := game.choosing || (game.gameOver && game.initials == nil)
+	if !title || game.session != nil || game.playback != nil || game.recordPath != "" || game.seats[0].input != 0 {
+		game.idle = 0
+		return false
+	}
+	if game.idle += timeStep; game.idle < attractDelay {
+		return false
+	}
+	game.reset()
+	game.attract = true
+	if game.choosing {
+		game.choosing = false
+		game.launch()
+		game.entities.flush()
+	}
+	return true
+}
+
+func (game *Game) drawAttract() {
+	if game.attract {
+		game.renderer.Print("Demo - press enter to play", game.screenWidth/2-100, game.screenHeight/2+120, 1, 1)
+	}
+}
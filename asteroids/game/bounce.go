@@ -0,0 +1,61 @@
+package game
+
+import (
+	"github.com/tanema/amore-examples/asteroids/game/phys"
+)
+
+const (
+	asteroidRestitution = 0.8
+	shieldRestitution   = 0.5
+)
+
+// bounce resolves a contact between two sprites with an impulse along the
+// contact normal, which points from b towards a, using their masses and
+// moments of inertia. Restitution is taken from the bouncier of the two, 1 keeps
+// all the energy and 0 none of it. They are also pushed apart so that they don't
+// stay sunk into each other.
+func bounce(a, b *Sprite, c *phys.Contact) {
+	nx, ny := c.NormalX, c.NormalY
+	// lever arms from each centre to the contact, which may be on a ghost
+	rax := nearest(c.X-a.x, a.game.screenWidth)
+	ray := nearest(c.Y-a.y, a.game.screenHeight)
+	rbx := nearest(c.X-b.x, b.game.screenWidth)
+	rby := nearest(c.Y-b.y, b.game.screenHeight)
+
+	invMassA, invInertiaA := a.inverseMass()
+	invMassB, invInertiaB := b.inverseMass()
+	if invMassA+invMassB == 0 {
+		return
+	}
+
+	correction := c.Depth / (invMassA + invMassB)
+	a.x, a.y = a.x+nx*correction*invMassA, a.y+ny*correction*invMassA
+	b.x, b.y = b.x-nx*correction*invMassB, b.y-ny*correction*invMassB
+
+	// relative velocity of the two points that touched
+	rvx := (a.vx - a.vrot*ray) - (b.vx - b.vrot*rby)
+	rvy := (a.vy + a.vrot*rax) - (b.vy + b.vrot*rbx)
+	closing := rvx*nx + rvy*ny
+	if closing > 0 {
+		return
+	}
+
+	armA := rax*ny - ray*nx
+	armB := rbx*ny - rby*nx
+	restitution := max(a.restitution, b.restitution)
+	j := -(1 + restitution) * closing / (invMassA + invMassB + armA*armA*invInertiaA + armB*armB*invInertiaB)
+
+	a.vx, a.vy = a.vx+j*nx*invMassA, a.vy+j*ny*invMassA
+	a.vrot += armA * j * invInertiaA
+	b.vx, b.vy = b.vx-j*nx*invMassB, b.vy-j*ny*invMassB
+	b.vrot -= armB * j * invInertiaB
+}
+
+// inverseMass returns one over the sprite's mass and moment of inertia. A sprite
+// without any area can't be pushed around so both are zero.
+func (sprite *Sprite) inverseMass() (float32, float32) {
+	if sprite.mass == 0 || sprite.inertia == 0 {
+		return 0, 0
+	}
+	return 1 / sprite.mass, 1 / sprite.inertia
+}
@@ -1,47 +1,61 @@
 package game
 
-const (
-	bulletSpeed = 500
-)
-
+// Bullet is a shot fired by the seat's ship or, if it has no owner, by a saucer.
 type Bullet struct {
 	*Sprite
+	owner  *seat
+	pierce int
 }
 
-func newBullet(x, y, rot float32) *Bullet {
+func newBullet(game *Game, x, y, rot float32, owner *seat) *Bullet {
 	vectorx := sin(rot)
 	vectory := -cos(rot)
 
-	bullet := &Bullet{}
-	bullet.Sprite = NewSprite(bullet, "bullet", x+(vectorx*10), y+(vectory*10), 1,
+	bullet := &Bullet{owner: owner}
+	bullet.Sprite = NewSprite(game, bullet, "bullet", x+(vectorx*10), y+(vectory*10), 1,
 		[]float32{
 			-1, 0,
 			1, 0,
 		}, false)
+	switch {
+	case owner == nil:
+		bullet.body.SetFilter(categorySaucerBullet, maskSaucerBullet)
+	case game.versus:
+		// ships shoot each other in a versus round
+		bullet.body.SetFilter(categoryBullet, maskBullet|categoryShip)
+	default:
+		bullet.body.SetFilter(categoryBullet, maskBullet)
+	}
+	bullet.body.SetSwept(true)
 	bullet.rot = rot
-	bullet.vx = (bulletSpeed * vectorx)
-	bullet.vy = (bulletSpeed * vectory)
+	bullet.vx = (game.tuning.BulletSpeed * vectorx)
+	bullet.vy = (game.tuning.BulletSpeed * vectory)
 
 	return bullet
 }
 
-func (bullet *Bullet) Update(dt float32) {
-	if collisions := bullet.UpdateMovement(dt); len(collisions) > 0 {
+func (bullet *Bullet) Kind() Kind {
+	return KindBullet
+}
+
+// pierced is called when the bullet hits something. It carries on through as
+// long as it has pierce left.
+func (bullet *Bullet) pierced() {
+	if bullet.pierce--; bullet.pierce < 0 {
 		bullet.Destroy(false)
-		for _, c := range collisions {
-			if c.Name == "asteroid" {
-				score++
-				c.Collidable.Destroy(false)
-			}
-		}
 	}
+}
+
+func (bullet *Bullet) Update(dt float32) {
+	bullet.UpdateMovement(dt)
 
-	if bullet.x > screenWidth || bullet.x < 0 || bullet.y > screenHeight || bullet.y < 0 {
+	if bullet.x > bullet.game.screenWidth || bullet.x < 0 || bullet.y > bullet.game.screenHeight || bullet.y < 0 {
 		bullet.Destroy(false)
 	}
 }
 
 func (bullet *Bullet) Destroy(force bool) {
-	removeObject(bullet)
-	bullet.Sprite.Destroy()
+	if bullet.game.entities.Despawn(bullet) {
+		bullet.Sprite.Destroy()
+	}
 }
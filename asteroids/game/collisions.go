@@ -0,0 +1,75 @@
+package game
+
+import (
+	"github.com/tanema/amore-examples/asteroids/game/phys"
+)
+
+const (
+	categoryShip phys.Category = 1 << iota
+	categoryAsteroid
+	categoryBullet
+	categorySaucer
+	categorySaucerBullet
+	categoryPickup
+
+	maskShip         = categoryAsteroid | categorySaucer | categorySaucerBullet | categoryPickup
+	maskAsteroid     = categoryShip | categoryAsteroid | categoryBullet | categorySaucer | categorySaucerBullet
+	maskBullet       = categoryAsteroid | categorySaucer
+	maskSaucer       = categoryShip | categoryAsteroid | categoryBullet
+	maskSaucerBullet = categoryShip | categoryAsteroid
+	maskPickup       = categoryShip
+)
+
+// registerCollisions declares what happens when two kinds of objects touch.
+// Masks already keep pairs that never interact, like a bullet and the ship that
+// fired it, from reaching the narrow phase.
+func (game *Game) registerCollisions() {
+	game.world.OnBegin(categoryShip, categoryAsteroid, func(ship, asteroid *phys.Body, c *phys.Contact) {
+		player := ship.Collidable.(*Player)
+		if player.shielded {
+			bounce(player.Sprite, asteroid.Collidable.(*Asteroid).Sprite, c)
+			return
+		}
+		player.hit()
+	})
+	game.world.OnBegin(categoryAsteroid, categoryAsteroid, func(a, b *phys.Body, c *phys.Contact) {
+		bounce(a.Collidable.(*Asteroid).Sprite, b.Collidable.(*Asteroid).Sprite, c)
+	})
+	game.world.OnBegin(categoryShip, categorySaucer, func(ship, saucer *phys.Body, c *phys.Contact) {
+		ship.Collidable.(*Player).hit()
+		saucer.Collidable.(*Saucer).hit(nil)
+	})
+	game.world.OnBegin(categoryShip, categorySaucerBullet, func(ship, bullet *phys.Body, c *phys.Contact) {
+		ship.Collidable.(*Player).hit()
+		bullet.Collidable.Destroy(false)
+	})
+	game.world.OnBegin(categoryBullet|categorySaucerBullet, categoryAsteroid, func(bullet, asteroid *phys.Body, c *phys.Contact) {
+		shot := bullet.Collidable.(*Bullet)
+		asteroid.Collidable.(*Asteroid).hit(c.X, c.Y, shot.vx, shot.vy, shot.owner)
+		newImpact(game, c.X, c.Y, c.NormalX, c.NormalY)
+		shot.pierced()
+	})
+	game.world.OnBegin(categoryBullet, categorySaucer, func(bullet, saucer *phys.Body, c *phys.Contact) {
+		saucer.Collidable.(*Saucer).hit(bullet.Collidable.(*Bullet).owner)
+		newImpact(game, c.X, c.Y, c.NormalX, c.NormalY)
+		bullet.Collidable.(*Bullet).pierced()
+	})
+	// only versus rounds let bullets reach ships, and never their own
+	game.world.OnBegin(categoryShip, categoryBullet, func(ship, bullet *phys.Body, c *phys.Contact) {
+		player, shot := ship.Collidable.(*Player), bullet.Collidable.(*Bullet)
+		if shot.owner == player.seat || player.invulnerable > 0 || !game.entities.Alive(player) {
+			return
+		}
+		player.hit()
+		game.addScore(shot.owner, versusPoints)
+		newImpact(game, c.X, c.Y, c.NormalX, c.NormalY)
+		shot.pierced()
+	})
+	game.world.OnBegin(categoryShip, categoryPickup, func(ship, pickup *phys.Body, c *phys.Contact) {
+		pickup.Collidable.(*Pickup).collected(ship.Collidable.(*Player))
+	})
+	game.world.OnBegin(categorySaucer, categoryAsteroid, func(saucer, asteroid *phys.Body, c *phys.Contact) {
+		asteroid.Collidable.(*Asteroid).hit(c.X, c.Y, -c.NormalX, -c.NormalY, nil)
+		saucer.Collidable.(*Saucer).hit(nil)
+	})
+}
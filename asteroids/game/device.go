@@ -0,0 +1,49 @@
+package game
+
+// Controls reports the state of the ship's controls for each simulation step.
+type Controls interface {
+	Read() Input
+}
+
+// Sound is a sample the game's Audio has loaded.
+type Sound int
+
+const (
+	SoundShot Sound = iota
+	SoundExplosion
+	SoundThrust
+	SoundBeatLow
+	SoundBeatHigh
+)
+
+// soundVoices is how many copies of each sound can play at the same time.
+var soundVoices = []int{
+	SoundShot:      4,
+	SoundExplosion: 4,
+	SoundThrust:    1,
+	SoundBeatLow:   1,
+	SoundBeatHigh:  1,
+}
+
+// Voices is how many copies of the sound the Audio needs to load so that it can
+// overlap with itself.
+func Voices(sound Sound) int {
+	return soundVoices[sound]
+}
+
+// Audio plays the game's sounds. Which voice of a sound to use is decided by
+// the game, so playing one never cuts off another.
+type Audio interface {
+	Play(sound Sound, voice int, volume, pitch float32)
+	Loop(sound Sound, playing bool)
+}
+
+// Renderer is everything the game needs to draw itself.
+type Renderer interface {
+	SetColor(r, g, b, a float32)
+	Line(x0, y0, x1, y1 float32)
+	Rect(x, y, width, height float32)
+	PolyLine(points []float32)
+	Circle(x, y, radius float32)
+	Print(text string, x, y, scaleX, scaleY float32)
+}
@@ -0,0 +1,157 @@
+package game
+
+const (
+	beatSlow   = 1.0
+	beatFast   = 0.25
+	beatVolume = 0.6
+)
+
+// eventKind is something that happened in the game that may make a sound.
+type eventKind int
+
+const (
+	eventShot eventKind = iota
+	eventSaucerShot
+	eventAsteroidDestroyed
+	eventSaucerDestroyed
+	eventShipDestroyed
+)
+
+type event struct {
+	kind eventKind
+	size asteroidSize
+}
+
+// cue is how an event sounds.
+type cue struct {
+	sound  Sound
+	volume float32
+	pitch  float32
+}
+
+// eventCues is the sound for each event, asteroidCues for each size of
+// asteroid, the bigger the deeper.
+var (
+	eventCues = []cue{
+		eventShot:            {sound: SoundShot, volume: 0.8, pitch: 1},
+		eventSaucerShot:      {sound: SoundShot, volume: 0.6, pitch: 1.3},
+		eventSaucerDestroyed: {sound: SoundExplosion, volume: 0.9, pitch: 0.85},
+		eventShipDestroyed:   {sound: SoundExplosion, volume: 1, pitch: 0.6},
+	}
+	asteroidCues = []cue{
+		asteroidLarge:  {sound: SoundExplosion, volume: 1, pitch: 0.7},
+		asteroidMedium: {sound: SoundExplosion, volume: 0.8, pitch: 1},
+		asteroidSmall:  {sound: SoundExplosion, volume: 0.6, pitch: 1.4},
+	}
+	// soundLength is roughly how long each sound plays, in seconds, so the
+	// director knows when a voice is free again.
+	soundLength = []float32{
+		SoundShot:      0.3,
+		SoundExplosion: 1.2,
+		SoundBeatLow:   0.2,
+		SoundBeatHigh:  0.2,
+	}
+)
+
+// emit queues an event for the audio director.
+func (game *Game) emit(ev event) {
+	game.events = append(game.events, ev)
+}
+
+// director turns what happens in the game into sound. It plays a cue for each
+// event, keeps the thrust loop going while the ship accelerates and beats the
+// heartbeat faster as the wave's asteroids are cleared.
+type director struct {
+	game      *Game
+	voices    [][]float32
+	next      []int
+	thrusting bool
+	beat      float32
+	high      bool
+}
+
+func newDirector(game *Game) *director {
+	voices := make([][]float32, len(soundVoices))
+	for sound, count := range soundVoices {
+		voices[sound] = make([]float32, count)
+	}
+	return &director{game: game, voices: voices, next: make([]int, len(soundVoices))}
+}
+
+// update plays everything that happened this step and clears the events.
+func (director *director) update(dt float32) {
+	game := director.game
+	defer func() { game.events = game.events[:0] }()
+	for _, ages := range director.voices {
+		for i := range ages {
+			ages[i] += dt
+		}
+	}
+	if game.audio == nil {
+		return
+	}
+
+	for _, ev := range game.events {
+		if ev.kind == eventAsteroidDestroyed {
+			director.play(asteroidCues[ev.size])
+		} else {
+			director.play(eventCues[ev.kind])
+		}
+	}
+
+	local := game.seats[game.local].player
+	thrusting := local != nil && local.isAccelerating
+	if thrusting != director.thrusting {
+		director.thrusting = thrusting
+		game.audio.Loop(SoundThrust, thrusting)
+	}
+
+	if game.choosing || game.gameOver {
+		return
+	}
+	if director.beat += dt; director.beat >= director.tempo() {
+		director.beat = 0
+		if director.high = !director.high; director.high {
+			director.play(cue{sound: SoundBeatHigh, volume: beatVolume, pitch: 1})
+		} else {
+			director.play(cue{sound: SoundBeatLow, volume: beatVolume, pitch: 1})
+		}
+	}
+}
+
+// tempo is the time between beats, from beatSlow with a full wave left down to
+// beatFast as the last asteroids are hunted down.
+func (director *director) tempo() float32 {
+	game := director.game
+	left := float32(game.entities.Count(KindAsteroid)) / float32(game.waveAsteroids())
+	return beatFast + (beatSlow-beatFast)*clamp(left, 0, 1)
+}
+
+// play puts the cue on a free voice of its sound, or on the one that has been
+// playing longest if they are all busy, so at most that many overlap.
+func (director *director) play(c cue) {
+	ages := director.voices[c.sound]
+	voice := director.next[c.sound]
+	for i, age := range ages {
+		if age >= soundLength[c.sound] {
+			voice = i
+			break
+		}
+		if age > ages[voice] {
+			voice = i
+		}
+	}
+	ages[voice] = 0
+	director.next[c.sound] = (voice + 1) % len(ages)
+	director.game.audio.Play(c.sound, voice, c.volume, c.pitch)
+}
+
+// stop silences the loops, used when a round is thrown away.
+func (director *director) stop() {
+	if director.thrusting && director.game.audio != nil {
+		director.game.audio.Loop(SoundThrust, false)
+	}
+	director.thrusting = false
+	// start the next round on a beat
+	director.beat = beatSlow
+}
@@ -0,0 +1,145 @@
+package game
+
+// EntityID identifies a spawned object for as long as the round lasts. IDs are
+// never reused.
+type EntityID uint64
+
+// Kind is the type of a game object, used to look objects up by type.
+type Kind int
+
+const (
+	KindPlayer Kind = iota
+	KindAsteroid
+	KindBullet
+	KindSaucer
+	KindExplosion
+	KindPickup
+	KindWell
+)
+
+// Entities holds every object in the game. Spawning and despawning only take
+// effect when the step is flushed so the objects never change while they are
+// being updated.
+type Entities struct {
+	nextID     EntityID
+	live       []GameObject
+	ids        map[GameObject]EntityID
+	byID       map[EntityID]GameObject
+	spawning   []GameObject
+	despawning map[EntityID]bool
+}
+
+func newEntities() *Entities {
+	return &Entities{
+		live:       []GameObject{},
+		ids:        map[GameObject]EntityID{},
+		byID:       map[EntityID]GameObject{},
+		spawning:   []GameObject{},
+		despawning: map[EntityID]bool{},
+	}
+}
+
+// Spawn queues the object to be added at the end of the step and returns its id.
+func (entities *Entities) Spawn(object GameObject) EntityID {
+	entities.nextID++
+	id := entities.nextID
+	entities.ids[object] = id
+	entities.byID[id] = object
+	entities.spawning = append(entities.spawning, object)
+	return id
+}
+
+// Despawn queues the object to be removed at the end of the step. It returns
+// false if the object was already despawned so that it is only destroyed once.
+func (entities *Entities) Despawn(object GameObject) bool {
+	id, ok := entities.ids[object]
+	if !ok || entities.despawning[id] {
+		return false
+	}
+	entities.despawning[id] = true
+	return true
+}
+
+// Alive reports if the object has been spawned and not despawned.
+func (entities *Entities) Alive(object GameObject) bool {
+	id, ok := entities.ids[object]
+	return ok && !entities.despawning[id]
+}
+
+// Get returns the object with the id if it hasn't been despawned.
+func (entities *Entities) Get(id EntityID) (GameObject, bool) {
+	object, ok := entities.byID[id]
+	if !ok || entities.despawning[id] {
+		return nil, false
+	}
+	return object, true
+}
+
+// Each calls fn for every live object in the order they were spawned, skipping
+// any that were despawned during this step.
+func (entities *Entities) Each(fn func(id EntityID, object GameObject)) {
+	for _, object := range entities.live {
+		if id := entities.ids[object]; !entities.despawning[id] {
+			fn(id, object)
+		}
+	}
+}
+
+// EachKind calls fn for every live object of the kind.
+func (entities *Entities) EachKind(kind Kind, fn func(id EntityID, object GameObject)) {
+	entities.Each(func(id EntityID, object GameObject) {
+		if object.Kind() == kind {
+			fn(id, object)
+		}
+	})
+}
+
+// Count is the number of live objects of the kind.
+func (entities *Entities) Count(kind Kind) int {
+	count := 0
+	entities.EachKind(kind, func(EntityID, GameObject) {
+		count++
+	})
+	return count
+}
+
+// Len is the number of live objects.
+func (entities *Entities) Len() int {
+	count := 0
+	entities.Each(func(EntityID, GameObject) {
+		count++
+	})
+	return count
+}
+
+// flush removes the despawned objects and adds the spawned ones.
+func (entities *Entities) flush() {
+	if len(entities.despawning) > 0 {
+		live := entities.live[:0]
+		for _, object := range entities.live {
+			id := entities.ids[object]
+			if entities.despawning[id] {
+				delete(entities.ids, object)
+				delete(entities.byID, id)
+				continue
+			}
+			live = append(live, object)
+		}
+		for i := len(live); i < len(entities.live); i++ {
+			entities.live[i] = nil
+		}
+		entities.live = live
+	}
+
+	for _, object := range entities.spawning {
+		id := entities.ids[object]
+		if entities.despawning[id] {
+			delete(entities.ids, object)
+			delete(entities.byID, id)
+			continue
+		}
+		entities.live = append(entities.live, object)
+	}
+	entities.spawning = entities.spawning[:0]
+	entities.despawning = map[EntityID]bool{}
+}
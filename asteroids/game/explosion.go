@@ -1,57 +1,108 @@
 package game
 
+import (
+	"math"
+
+	"github.com/tanema/amore-examples/asteroids/game/particles"
+)
+
 const (
-	explosionSpeed = 100
-	explosionTime  = 3
+	explosionTime    = 3
+	explosionDensity = 0.3
+	explosionMax     = 80
+	impactSparks     = 8
+	particleStreak   = 0.03
 )
 
+var explosionParticles = particles.Config{
+	LifeMin: explosionTime / 3,
+	LifeMax: explosionTime,
+	Spread:  math.Pi,
+	Drag:    0.5,
+	Colors: particles.Ramp{
+		{R: 255, G: 255, B: 255, A: 255},
+		{R: 255, G: 200, B: 80, A: 220},
+		{R: 200, G: 60, B: 20, A: 120},
+		{R: 100, G: 30, B: 10, A: 0},
+	},
+}
+
+var impactParticles = particles.Config{
+	LifeMin:  0.1,
+	LifeMax:  0.3,
+	SpeedMin: 60,
+	SpeedMax: 160,
+	Spread:   0.6,
+	Drag:     2,
+	Colors: particles.Ramp{
+		{R: 255, G: 255, B: 200, A: 255},
+		{R: 255, G: 160, B: 60, A: 0},
+	},
+}
+
+// Explosion is a one off burst of particles that removes itself once they have
+// all died out.
 type Explosion struct {
-	sprites []*Sprite
-	life    float32
+	game    *Game
+	emitter *particles.Emitter
 }
 
-func newExplosion(sprite *Sprite) {
-	points := sprite.GetPoints()
-	explosion := &Explosion{
-		sprites: []*Sprite{},
+// newExplosion bursts the outline into particles from its middle, the longer
+// the outline the more of them.
+func newExplosion(game *Game, points []float32) {
+	var x, y, length float32
+	count := len(points) / 2
+	for i := 0; i < len(points); i += 2 {
+		x, y = x+points[i], y+points[i+1]
+		if i+3 < len(points) {
+			dx, dy := points[i+2]-points[i], points[i+3]-points[i+1]
+			length += sqrt(dx*dx + dy*dy)
+		}
 	}
-
-	for i := 0; i < len(points)-2; i += 2 {
-		explosion.addSegment(points[i], points[i+1], points[i+2], points[i+3])
+	if count == 0 {
+		return
 	}
+	config := explosionParticles
+	config.SpeedMin, config.SpeedMax = game.tuning.ExplosionSpeed/4, game.tuning.ExplosionSpeed
+	emitter := particles.NewEmitter(config, game.fx)
+	emitter.X, emitter.Y = x/float32(count), y/float32(count)
+	emitter.Burst(int(min(explosionMax, length*explosionDensity+1)))
+	game.entities.Spawn(&Explosion{game: game, emitter: emitter})
+}
 
-	addObject(explosion)
+// newImpact throws sparks off a surface at x, y in the direction of its normal.
+func newImpact(game *Game, x, y, nx, ny float32) {
+	emitter := particles.NewEmitter(impactParticles, game.fx)
+	emitter.X, emitter.Y = x, y
+	emitter.Direction = atan2(nx, -ny)
+	emitter.Burst(impactSparks)
+	game.entities.Spawn(&Explosion{game: game, emitter: emitter})
 }
 
-func (explosion *Explosion) addSegment(x0, y0, x1, y1 float32) {
-	sprite := NewSprite(nil, "explosion", 0, 0, 1, []float32{x0, y0, x1, y1}, false)
-	rot := atan2(x1-x0, y1-y0)
-	vectorx := sin(rot)
-	vectory := -cos(rot)
-	sprite.vx = explosionSpeed * vectorx
-	sprite.vy = explosionSpeed * vectory
-	explosion.sprites = append(explosion.sprites, sprite)
+func (explosion *Explosion) Kind() Kind {
+	return KindExplosion
 }
 
 func (explosion *Explosion) Update(dt float32) {
-	for _, sprite := range explosion.sprites {
-		sprite.UpdateMovement(dt)
-	}
-	explosion.life += dt
-	if explosion.life >= explosionTime {
+	explosion.emitter.Update(dt)
+	if explosion.emitter.Done() {
 		explosion.Destroy(false)
 	}
 }
 
 func (explosion *Explosion) Draw() {
-	for _, sprite := range explosion.sprites {
-		sprite.Draw()
-	}
+	explosion.game.drawParticles(explosion.emitter)
 }
 
 func (explosion *Explosion) Destroy(force bool) {
-	removeObject(explosion)
-	for _, sprite := range explosion.sprites {
-		sprite.Destroy()
-	}
+	explosion.game.entities.Despawn(explosion)
+}
+
+// drawParticles draws each particle as a short streak behind it.
+func (game *Game) drawParticles(emitter *particles.Emitter) {
+	emitter.Each(func(particle particles.Particle, color particles.Color) {
+		game.renderer.SetColor(color.R, color.G, color.B, color.A)
+		game.renderer.Line(particle.X, particle.Y, particle.X-particle.VX*particleStreak, particle.Y-particle.VY*particleStreak)
+	})
+	game.renderer.SetColor(255, 255, 255, 255)
 }
@@ -2,106 +2,337 @@ package game
 
 import (
 	"fmt"
+	"math/rand"
+	"time"
 
 	"github.com/tanema/amore-examples/asteroids/game/phys"
-
-	"github.com/tanema/amore/audio"
-	"github.com/tanema/amore/gfx"
-	"github.com/tanema/amore/keyboard"
+	"github.com/tanema/amore-examples/highscore"
 )
 
-var debug bool
-
 type GameObject interface {
+	Kind() Kind
 	Update(dt float32)
 	Draw()
 	Destroy(force bool)
 }
 
 const (
-	cellSize float32 = 60
+	timeStep float32 = 1.0 / 60.0
+	maxSteps         = 5
 )
 
-var (
-	score        = 0
+// Game is a single game of asteroids. It only talks to the outside world through
+// its Controls, Audio and Renderer so it can be run without a window.
+type Game struct {
+	debug        bool
+	seats        []*seat
+	local        int
+	versus       bool
+	wave         int
+	waveTimer    float32
+	saucer       *Saucer
+	saucerTimer  float32
 	world        *phys.World
-	objects      []GameObject
-	bomb, _      = audio.NewSource("../test-all/assets/audio/bomb.wav", true)
-	lazer, _     = audio.NewSource("audio/lazer.wav", true)
-	player       *Player
-	gameOver     = false
+	entities     *Entities
+	gameOver     bool
 	screenWidth  float32
 	screenHeight float32
-)
-
-func New() {
-	keyboard.OnKeyUp = keyup
-	screenWidth = gfx.GetWidth()
-	screenHeight = gfx.GetHeight()
-	reset()
+	rng          *rand.Rand
+	fx           *rand.Rand
+	accumulator  float32
+	frame        int
+	recordPath   string
+	recording    *Replay
+	playback     *Replay
+	scores       map[Mode]*highscore.Table
+	initials     *highscore.Initials
+	controls     Controls
+	audio        Audio
+	renderer     Renderer
+	ships        []ShipClass
+	ship         int
+	mode         Mode
+	wells        []*Well
+	choosing     bool
+	tuning       Tuning
+	// the ships and tuning as last loaded, which the ones in use are set to
+	// at the start of every round that isn't a replay
+	loadedShips  []ShipClass
+	loadedTuning Tuning
+	shipsFile    *watchedFile
+	tuningFile   *watchedFile
+	watchTimer   float32
+	seed         int64
+	session      *session
+	netStatus    string
+	autopilot    *autopilot
+	director     *director
+	events       []event
+	attract      bool
+	idle         float32
 }
 
-func reset() {
-	gameOver = false
-	score = 0
-	world = phys.NewWorld(screenWidth, screenHeight, cellSize)
-	player = newPlayer()
-	objects = []GameObject{
-		player,
-		newAsteroid(),
-		newAsteroid(),
-		newAsteroid(),
-		newAsteroid(),
-		newAsteroid(),
-		newAsteroid(),
-		newAsteroid(),
+// New creates a game on a playfield of the given size. controls and audio may be
+// nil to run the game headless, and renderer is only needed to call Draw.
+func New(width, height float32, controls Controls, audio Audio, renderer Renderer) *Game {
+	game := &Game{
+		screenWidth:  width,
+		screenHeight: height,
+		controls:     controls,
+		audio:        audio,
+		renderer:     renderer,
+		ships:        defaultShips,
+		tuning:       defaultTuning,
+		loadedShips:  defaultShips,
+		loadedTuning: defaultTuning,
+		scores:       map[Mode]*highscore.Table{},
 	}
+	game.autopilot = newAutopilot(game, 0)
+	game.director = newDirector(game)
+	game.Reset()
+	return game
+}
+
+// Record saves the input of the current round to path so that it can be played
+// back with Play. The file is written when the round ends or is restarted.
+func (game *Game) Record(path string) {
+	game.recordPath = path
+	game.Reset()
+}
+
+// Play resets the game and plays the replay back, restarting it whenever it runs
+// out of input.
+func (game *Game) Play(replay *Replay) {
+	game.playback = replay
+	game.Reset()
+}
+
+// Stop saves any round that is still being recorded.
+func (game *Game) Stop() {
+	game.saveRecording()
+}
+
+// Reset starts a new round, leaving any network session.
+func (game *Game) Reset() {
+	game.leaveSession("")
+	game.netStatus = ""
+	game.attract = false
+	game.idle = 0
+	game.reset()
 }
 
-func keyup(key keyboard.Key) {
-	if key == keyboard.KeyTab {
-		debug = !debug
+func (game *Game) reset() {
+	game.saveRecording()
+	game.director.stop()
+	game.events = game.events[:0]
+
+	seed := time.Now().UTC().UnixNano()
+	game.useShips(game.loadedShips)
+	game.tuning = game.loadedTuning
+	if game.session != nil {
+		seed = game.session.welcome.Seed
+	} else if game.playback != nil {
+		seed = game.playback.Seed
+		game.useShips(game.playback.Ships)
+		game.tuning = game.playback.Tuning
+		game.mode, game.ship = game.playback.Mode, game.playback.Ship
+		if game.mode < 0 || game.mode >= modeCount || game.ship < 0 || game.ship >= len(game.ships) {
+			game.mode, game.ship = ModeClassic, 0
+		}
+	} else if game.recordPath != "" {
+		game.recording = newReplay(seed, game.mode, game.ship, game.ships, game.tuning)
+	}
+	game.seed = seed
+	game.rng = rand.New(rand.NewSource(seed))
+	// particles get their own source so that tuning them doesn't change what
+	// happens in recorded rounds
+	game.fx = rand.New(rand.NewSource(seed))
+	game.accumulator = 0
+	game.frame = 0
+
+	game.gameOver = false
+	game.initials = nil
+	game.seats = []*seat{{lives: startingLives, ship: game.ship}}
+	game.local, game.versus = 0, false
+	if game.session != nil {
+		game.seats = game.session.seats()
+		game.local, game.versus = game.session.seat, game.session.welcome.Versus
 	}
-	if key == keyboard.KeyReturn {
-		reset()
+	game.wave = 0
+	game.waveTimer = 0
+	game.saucer = nil
+	game.saucerTimer = 0
+	game.wells = nil
+	game.world = phys.NewWorld(game.screenWidth, game.screenHeight, game.tuning.CellSize)
+	game.registerCollisions()
+	game.entities = newEntities()
+	// the round waits on the start screen until a ship and mode are picked,
+	// which for a network round was done by the host
+	if game.choosing = game.session == nil; !game.choosing {
+		game.launch()
+		game.entities.flush()
 	}
 }
 
-func Update(dt float32) {
-	for _, object := range objects {
-		object.Update(dt)
+// ToggleDebug switches drawing of the physics grid and bodies.
+func (game *Game) ToggleDebug() {
+	game.debug = !game.debug
+}
+
+// Score is the current round's score of the player at this end.
+func (game *Game) Score() int {
+	return game.seats[game.local].score
+}
+
+// Lives is how many ships the player at this end has left, including the one in
+// play.
+func (game *Game) Lives() int {
+	return game.seats[game.local].lives
+}
+
+// Wave is the number of the wave being played.
+func (game *Game) Wave() int {
+	return game.wave
+}
+
+// IsOver reports if the current round has ended.
+func (game *Game) IsOver() bool {
+	return game.gameOver
+}
+
+func (game *Game) saveRecording() {
+	if game.recording == nil {
+		return
+	}
+	if err := game.recording.Save(game.recordPath); err != nil {
+		fmt.Println("could not save recording:", err)
 	}
-	gameOver = gameOver || len(objects) == 1
+	game.recording = nil
 }
 
-func addObject(object GameObject) {
-	objects = append(objects, object)
+// Update advances the simulation in fixed steps so that a round plays out the
+// same way no matter the frame rate it is run at.
+func (game *Game) Update(dt float32) {
+	if game.session != nil {
+		game.updateSession(dt)
+		return
+	}
+	game.updateWatched(dt)
+	game.accumulator += dt
+	for steps := 0; game.accumulator >= timeStep; steps++ {
+		if steps == maxSteps {
+			game.accumulator = 0
+			break
+		}
+		game.accumulator -= timeStep
+		game.step()
+	}
 }
 
-func removeObject(object GameObject) {
-	for i, other := range objects {
-		if object == other {
-			objects = append(objects[:i], objects[i+1:]...)
+// step reads the input of a single player round and simulates it.
+func (game *Game) step() {
+	seat := game.seats[0]
+	if game.playback != nil {
+		var ok bool
+		if seat.input, ok = game.playback.frame(game.frame); !ok {
+			game.Reset()
 			return
 		}
+	} else if game.controls != nil {
+		seat.input = game.controls.Read()
+	}
+	if game.attract {
+		if seat.input != 0 || game.gameOver {
+			game.Reset()
+			return
+		}
+		seat.input = game.autopilot.Read()
+	}
+	if game.recording != nil {
+		game.recording.record(seat.input)
+	}
+	game.simulate()
+}
+
+// simulate advances the round by one step with the input every seat has been
+// given.
+func (game *Game) simulate() {
+	game.frame++
+	defer func() {
+		for _, seat := range game.seats {
+			seat.lastInput = seat.input
+		}
+	}()
+	if game.updateAttract() {
+		return
+	}
+
+	if game.choosing {
+		game.updateChoice()
+		game.entities.flush()
+		return
+	}
+
+	game.updateWells()
+	game.entities.Each(func(id EntityID, object GameObject) {
+		object.Update(timeStep)
+	})
+	game.updateWave(timeStep)
+	game.updateRespawn(timeStep)
+	game.updateSaucer(timeStep)
+	game.world.Step()
+	game.entities.flush()
+	game.director.update(timeStep)
+	if game.gameOver {
+		game.saveRecording()
 	}
 }
 
-func Draw() {
-	if debug {
-		world.DrawGrid()
-		gfx.Print(fmt.Sprintf("objects: %v", len(objects)), 0, 15)
+// drawSeat shows a seat's score, lives and ship status in a column at x, y.
+func (game *Game) drawSeat(seat *seat, x, y float32) {
+	if len(game.seats) > 1 {
+		color := seatColors[seat.index]
+		game.renderer.SetColor(color[0], color[1], color[2], 255)
+		game.renderer.Print(fmt.Sprintf("Player %v", seat.index+1), x, y, 1, 1)
+		y += 15
+	}
+	game.renderer.Print(fmt.Sprintf("Score: %v", seat.score), x, y, 1, 1)
+	game.renderer.Print(fmt.Sprintf("Wave: %v", game.wave), x, y+15, 1, 1)
+	game.renderer.Print(fmt.Sprintf("Lives: %v", seat.lives), x, y+30, 1, 1)
+	if player := seat.player; player != nil {
+		game.renderer.Print(fmt.Sprintf("Shield: %v%%", int(100*player.shield/game.tuning.PlayerShieldTime)), x, y+45, 1, 1)
+		player.drawHyperspace(x, y+60)
+		player.drawModifiers(x, y+75)
+	}
+	game.renderer.SetColor(255, 255, 255, 255)
+}
+
+func (game *Game) Draw() {
+	if game.debug {
+		game.world.DrawGrid(game.renderer)
+		game.renderer.Print(fmt.Sprintf("objects: %v", game.entities.Len()), 0, 15, 1, 1)
+		game.renderer.Print(fmt.Sprintf("physical objects: %v", game.world.Count()), 0, 30, 1, 1)
+	}
+
+	game.drawDataErrors()
+	game.drawSession()
+	game.drawAttract()
+	if game.choosing {
+		game.drawChoice()
+		return
 	}
 
-	for _, object := range objects {
+	game.entities.Each(func(id EntityID, object GameObject) {
 		object.Draw()
+	})
+	for i, seat := range game.seats {
+		game.drawSeat(seat, game.screenWidth-100-float32(len(game.seats)-1-i)*160, 0)
 	}
-	gfx.Print(fmt.Sprintf("Score: %v", score), screenWidth-100, 0)
-	if gameOver {
-		if len(objects) == 1 && objects[0] == player {
-			gfx.Print("Game Over. You Won.", screenWidth/2-175, screenHeight/2, 0, 2, 3)
-		} else {
-			gfx.Print("Game Over. You Lost.", screenWidth/2-175, screenHeight/2, 0, 2, 2)
+	if game.gameOver {
+		game.renderer.Print("Game Over.", game.screenWidth/2-100, game.screenHeight/2-120, 2, 2)
+		game.drawHighScores(game.screenWidth/2-100, game.screenHeight/2-80)
+		if game.initials == nil {
+			game.renderer.Print("Press enter to play again", game.screenWidth/2-100, game.screenHeight/2+80, 1, 1)
 		}
 	}
 }
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// scripted turns, thrusts and fires in a fixed pattern.
+type scripted struct {
+	frame int
+}
+
+func (controls *scripted) Read() Input {
+	controls.frame++
+	input := Input(0)
+	if controls.frame%10 < 5 {
+		input |= InputFire
+	}
+	if controls.frame%90 < 30 {
+		input |= InputLeft
+	}
+	if controls.frame%120 < 20 {
+		input |= InputThrust
+	}
+	return input
+}
+
+// outcome is what can be seen of a round from the outside.
+type outcome struct {
+	score, lives, wave, entities int
+	x, y                         float32
+}
+
+func play(game *Game, steps int) outcome {
+	for i := 0; i < steps && !game.IsOver(); i++ {
+		game.Update(timeStep)
+	}
+	result := outcome{score: game.Score(), lives: game.Lives(), wave: game.Wave(), entities: game.entities.Len()}
+	if player := game.seats[0].player; player != nil {
+		result.x, result.y = player.x, player.y
+	}
+	return result
+}
+
+// scriptedReplay is a replay of the scripted controls from a fixed seed.
+func scriptedReplay(seed int64, steps int) *Replay {
+	replay := newReplay(seed, ModeClassic, 0, defaultShips, defaultTuning)
+	controls := &scripted{}
+	for i := 0; i < steps; i++ {
+		replay.record(controls.Read())
+	}
+	return replay
+}
+
+func TestHeadless(t *testing.T) {
+	const steps = 1200
+	game := New(800, 600, nil, nil, nil)
+	game.Play(scriptedReplay(1, steps+1))
+	first := play(game, steps)
+	if first.score == 0 || first.wave == 0 || first.entities == 0 {
+		t.Fatalf("nothing happened in %v steps: %+v", steps, first)
+	}
+
+	again := New(800, 600, nil, nil, nil)
+	again.Play(scriptedReplay(1, steps+1))
+	if second := play(again, steps); second != first {
+		t.Errorf("the same seed and input played out differently: %+v and %+v", first, second)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	const steps = 1500
+	path := filepath.Join(t.TempDir(), "round.json")
+	game := New(800, 600, &scripted{}, nil, nil)
+	game.Record(path)
+	recorded := play(game, steps)
+	game.Stop()
+
+	replay, err := LoadReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay.Frames) == 0 {
+		t.Fatal("nothing was recorded")
+	}
+	playback := New(800, 600, nil, nil, nil)
+	playback.Play(replay)
+	if played := play(playback, len(replay.Frames)); played != recorded {
+		t.Errorf("the replay played out differently: recorded %+v, played %+v", recorded, played)
+	}
+}
@@ -0,0 +1,17 @@
+package game
+
+// Input is the state of the ship's controls for a single simulation step.
+type Input uint8
+
+const (
+	InputLeft Input = 1 << iota
+	InputRight
+	InputThrust
+	InputFire
+	InputShield
+	InputHyperspace
+)
+
+func (input Input) has(flag Input) bool {
+	return input&flag == flag
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// loopbackWait is how long the loopback run waits on the network before giving
+// up on it.
+const loopbackWait = 10 * time.Second
+
+// LoopbackReport is how a run of Loopback went.
+type LoopbackReport struct {
+	Frames   int
+	Stalls   int
+	Rounds   int
+	Problems []string
+}
+
+func (report LoopbackReport) String() string {
+	lines := []string{
+		fmt.Sprintf("simulated %v frames over %v rounds", report.Frames, report.Rounds),
+		fmt.Sprintf("waited on the network %v times", report.Stalls),
+		fmt.Sprintf("problems %v", len(report.Problems)),
+	}
+	lines = append(lines, report.Problems...)
+	return strings.Join(lines, "\n")
+}
+
+// Loopback hosts a network round and joins it from a second game in the same
+// process over the loopback interface, with an autopilot flying each ship. It
+// plays for the given amount of game time, as fast as the two can keep up, and
+// reports any desync or lost connection. Each time a round ends the other mode
+// is hosted.
+func Loopback(width, height float32, duration time.Duration, versus bool) LoopbackReport {
+	report := LoopbackReport{}
+	frames := int(math.Round(duration.Seconds() / float64(timeStep)))
+	host := New(width, height, nil, nil, nil)
+	guest := New(width, height, nil, nil, nil)
+	host.controls = newAutopilot(host, 0)
+	guest.controls = newAutopilot(guest, 1)
+
+	for report.Frames < frames {
+		host.mode = Mode(report.Rounds) % modeCount
+		report.Rounds++
+		if err := host.Host("127.0.0.1:0", versus); err != nil {
+			report.Problems = append(report.Problems, err.Error())
+			return report
+		}
+		if err := guest.Join(host.session.localAddr().String()); err != nil {
+			host.Reset()
+			report.Problems = append(report.Problems, err.Error())
+			return report
+		}
+		if problem := playLoopback(host, guest, frames, &report); problem != "" {
+			report.Problems = append(report.Problems, problem)
+			return report
+		}
+		host.Reset()
+		guest.Reset()
+	}
+	return report
+}
+
+// playLoopback steps both games until the round is over or enough frames have
+// been played.
+func playLoopback(host, guest *Game, frames int, report *LoopbackReport) string {
+	start := report.Frames
+	waiting := time.Now()
+	for {
+		before := host.frame + guest.frame
+		host.Update(timeStep)
+		guest.Update(timeStep)
+		for _, game := range []*Game{host, guest} {
+			if game.session == nil {
+				return fmt.Sprintf("seed %v: %v", game.seed, game.netStatus)
+			}
+		}
+		if host.session.lock == nil || guest.session.lock == nil || host.frame+guest.frame == before {
+			if time.Since(waiting) > loopbackWait {
+				return "nothing came through the loopback interface"
+			}
+			report.Stalls++
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		waiting = time.Now()
+		played := host.session.lock.Frame()
+		if other := guest.session.lock.Frame(); other < played {
+			played = other
+		}
+		report.Frames = start + played
+		if report.Frames >= frames || (host.gameOver && guest.gameOver) {
+			return ""
+		}
+	}
+}
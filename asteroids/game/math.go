@@ -2,14 +2,8 @@ package game
 
 import (
 	"math"
-	"math/rand"
-	"time"
 )
 
-func init() {
-	rand.Seed(time.Now().UTC().UnixNano())
-}
-
 func floor(x float32) float32 {
 	return float32(math.Floor(float64(x)))
 }
@@ -23,16 +17,16 @@ func clamp(x, minX, maxX float32) float32 {
 	return x
 }
 
-func randMax(max float32) float32 {
-	return randRange(0, max)
+func (game *Game) randMax(max float32) float32 {
+	return game.randRange(0, max)
 }
 
-func randRange(min, max float32) float32 {
-	return (rand.Float32() * (max - min)) + min
+func (game *Game) randRange(min, max float32) float32 {
+	return (game.rng.Float32() * (max - min)) + min
 }
 
-func randLimits(limit float32) float32 {
-	return randRange(-limit, limit)
+func (game *Game) randLimits(limit float32) float32 {
+	return game.randRange(-limit, limit)
 }
 
 func abs(x float32) float32 {
@@ -0,0 +1,24 @@
+package game
+
+// Mode is the set of rules a round is played by. It is picked on the start
+// screen along with the ship.
+type Mode int
+
+const (
+	ModeClassic Mode = iota
+	ModeGravity
+	modeCount
+)
+
+// modes is the name and a one line summary of each mode for the start screen.
+var modes = []struct {
+	name    string
+	summary string
+}{
+	ModeClassic: {name: "Classic", summary: "clear the asteroids and look out for saucers"},
+	ModeGravity: {name: "Gravity wells", summary: "wells pull everything in and swallow what falls in"},
+}
+
+func (mode Mode) String() string {
+	return modes[mode].name
+}
@@ -0,0 +1,190 @@
+package netplay
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// queueSize is how many packets can wait to be polled before more are
+	// dropped, the same as if the network had lost them.
+	queueSize = 256
+	// a failed read waits readBackoff before trying again, twice as long after
+	// every failure in a row, and reading gives up after maxReadErrors
+	readBackoff   = 10 * time.Millisecond
+	maxReadErrors = 8
+)
+
+// ReadError is the last packet of a connection whose socket kept failing to
+// read. Nothing more will arrive on it.
+type ReadError struct {
+	Err error
+}
+
+func (err ReadError) Error() string {
+	return fmt.Sprintf("could not read from the network: %v", err.Err)
+}
+
+// Packet is a message as it arrived. Err is set instead of Message if it
+// couldn't be decoded or is a ReadError, which has no From.
+type Packet struct {
+	Message Message
+	From    *net.UDPAddr
+	Err     error
+}
+
+// Conn is a UDP socket talking to one other instance. Packets are read in the
+// background and handed over by Poll so the game loop never blocks on the
+// network.
+type Conn struct {
+	socket   *net.UDPConn
+	queue    chan Packet
+	mutex    sync.Mutex
+	peer     *net.UDPAddr
+	closed   bool
+	closeErr error
+}
+
+// Listen opens a socket on addr for a host to wait for a joiner on. It has no
+// peer until SetPeer is called.
+func Listen(addr string) (*Conn, error) {
+	local, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	socket, err := net.ListenUDP("udp", local)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(socket, nil), nil
+}
+
+// Dial opens a socket that talks to the host at addr.
+func Dial(addr string) (*Conn, error) {
+	remote, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	socket, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(socket, remote), nil
+}
+
+func newConn(socket *net.UDPConn, peer *net.UDPAddr) *Conn {
+	conn := &Conn{socket: socket, queue: make(chan Packet, queueSize), peer: peer}
+	go conn.read()
+	return conn
+}
+
+// read hands packets over to the queue until the connection is closed. Errors
+// like a packet being refused on the way out can come up on a working socket,
+// so reading backs off and tries again for a while before giving up.
+func (conn *Conn) read() {
+	defer close(conn.queue)
+	buf := make([]byte, 2048)
+	failures := 0
+	for {
+		n, from, err := conn.socket.ReadFromUDP(buf)
+		if err != nil {
+			conn.mutex.Lock()
+			closed := conn.closed
+			conn.mutex.Unlock()
+			if closed {
+				return
+			}
+			if failures++; failures == maxReadErrors {
+				select {
+				case conn.queue <- Packet{Err: ReadError{Err: err}}:
+				default:
+				}
+				return
+			}
+			time.Sleep(readBackoff << (failures - 1))
+			continue
+		}
+		failures = 0
+		msg, err := Decode(buf[:n])
+		if err == errNotOurs {
+			continue
+		}
+		select {
+		case conn.queue <- Packet{Message: msg, From: from, Err: err}:
+		default:
+		}
+	}
+}
+
+// Poll returns every packet that has arrived since the last call. Once there
+// is a peer only its packets are returned.
+func (conn *Conn) Poll() []Packet {
+	packets := []Packet{}
+	peer := conn.Peer()
+	for {
+		select {
+		case packet, ok := <-conn.queue:
+			if !ok {
+				return packets
+			}
+			if peer == nil || packet.From == nil || sameAddr(packet.From, peer) {
+				packets = append(packets, packet)
+			}
+		default:
+			return packets
+		}
+	}
+}
+
+// SetPeer makes addr the only instance the connection talks to.
+func (conn *Conn) SetPeer(addr *net.UDPAddr) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	conn.peer = addr
+}
+
+// Peer is the other instance, or nil if a host hasn't been joined yet.
+func (conn *Conn) Peer() *net.UDPAddr {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	return conn.peer
+}
+
+// LocalAddr is the address the socket is bound to.
+func (conn *Conn) LocalAddr() *net.UDPAddr {
+	return conn.socket.LocalAddr().(*net.UDPAddr)
+}
+
+// Send writes the message to the peer.
+func (conn *Conn) Send(msg Message) error {
+	return conn.SendTo(msg, conn.Peer())
+}
+
+// SendTo writes the message to addr, used to turn away instances that aren't
+// the peer.
+func (conn *Conn) SendTo(msg Message, addr *net.UDPAddr) error {
+	if addr == nil {
+		return nil
+	}
+	_, err := conn.socket.WriteToUDP(Encode(msg), addr)
+	return err
+}
+
+// Close shuts the socket.
+func (conn *Conn) Close() error {
+	conn.mutex.Lock()
+	if conn.closed {
+		conn.mutex.Unlock()
+		return conn.closeErr
+	}
+	conn.closed = true
+	conn.mutex.Unlock()
+	conn.closeErr = conn.socket.Close()
+	return conn.closeErr
+}
+
+func sameAddr(a, b *net.UDPAddr) bool {
+	return a.IP.Equal(b.IP) && a.Port == b.Port
+}
@@ -0,0 +1,171 @@
+package netplay
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, msg := range []Message{
+		Hello{Rules: 7, Ship: 2},
+		Welcome{Rules: 1, Seed: -99, Mode: 1, Versus: true, Delay: 3, Ships: [2]uint8{1, 2}},
+		Reject{Reason: "nope"},
+		Inputs{First: 5, Inputs: []byte{1, 2, 3}, Ack: 9, Checked: 4, Checksum: 0xdeadbeef},
+		Inputs{First: 5, Inputs: []byte{}, Ack: 9},
+		Bye{},
+	} {
+		if got, err := Decode(Encode(msg)); err != nil || !reflect.DeepEqual(got, msg) {
+			t.Errorf("%#v came back as %#v, %v", msg, got, err)
+		}
+	}
+
+	packet := Encode(Hello{})
+	packet[2] = Version + 1
+	if _, err := Decode(packet); err == nil {
+		t.Error("a hello from another version should be an error")
+	}
+	packet = Encode(Reject{Reason: "old"})
+	packet[2] = Version + 1
+	if msg, err := Decode(packet); err != nil || msg.(Reject).Reason != "old" {
+		t.Error("a reject should be understood across versions")
+	}
+	if _, err := Decode(Encode(Inputs{Inputs: []byte{1}})[:8]); err == nil {
+		t.Error("a cut off packet should be an error")
+	}
+}
+
+// connect joins two connections on loopback the way a game session does, the
+// host taking whoever says hello first as its peer.
+func connect(t *testing.T) (host, guest *Conn) {
+	t.Helper()
+	host, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	guest, err = Dial(host.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		host.Close()
+		guest.Close()
+	})
+	deadline := time.Now().Add(2 * time.Second)
+	for host.Peer() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the host never heard hello")
+		}
+		guest.Send(Hello{})
+		time.Sleep(time.Millisecond)
+		for _, packet := range host.Poll() {
+			if _, ok := packet.Message.(Hello); ok {
+				host.SetPeer(packet.From)
+			}
+		}
+	}
+	return host, guest
+}
+
+// end is one side of a lockstep round with a stand in for the game's state.
+type end struct {
+	lock  *Lockstep
+	state uint32
+	seen  [][2]byte
+}
+
+func (end *end) update(input byte) {
+	end.lock.Poll()
+	end.lock.Push(input)
+	for {
+		inputs, ok := end.lock.Next()
+		if !ok {
+			break
+		}
+		end.seen = append(end.seen, inputs)
+		end.state = end.state*31 + uint32(inputs[0]) + uint32(inputs[1])*7
+		end.lock.Check(end.state)
+	}
+	end.lock.Flush()
+}
+
+// run plays both ends until both have simulated frames, calling tamper with each
+// end's frame first.
+func run(t *testing.T, ends [2]*end, frames int, tamper func(seat int, end *end)) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; ends[0].lock.Frame() < frames || ends[1].lock.Frame() < frames; i++ {
+		if time.Now().After(deadline) {
+			t.Fatalf("stuck at frames %v and %v", ends[0].lock.Frame(), ends[1].lock.Frame())
+		}
+		for seat, end := range ends {
+			if tamper != nil {
+				tamper(seat, end)
+			}
+			end.update(byte(i*(seat+1)) % 32)
+		}
+		time.Sleep(200 * time.Microsecond)
+	}
+}
+
+func TestLockstep(t *testing.T) {
+	host, guest := connect(t)
+	ends := [2]*end{{lock: NewLockstep(host, 0, 3)}, {lock: NewLockstep(guest, 1, 3)}}
+	run(t, ends, 120, nil)
+	// let the last checksums get across
+	run(t, ends, 125, nil)
+
+	if !reflect.DeepEqual(ends[0].seen[:120], ends[1].seen[:120]) {
+		t.Error("the two ends simulated different input")
+	}
+	if ends[0].seen[50] == [2]byte{} || ends[0].seen[50][0] == ends[0].seen[50][1] {
+		t.Errorf("frame 50 should have different input from each end, had %v", ends[0].seen[50])
+	}
+	for seat, end := range ends {
+		if frame, desync := end.lock.Desync(); desync {
+			t.Errorf("seat %v saw a desync at frame %v", seat, frame)
+		}
+		if end.lock.checked < 100 {
+			t.Errorf("seat %v only checked up to frame %v", seat, end.lock.checked)
+		}
+	}
+}
+
+func TestLockstepDesync(t *testing.T) {
+	host, guest := connect(t)
+	ends := [2]*end{{lock: NewLockstep(host, 0, 3)}, {lock: NewLockstep(guest, 1, 3)}}
+	bumped := -1
+	run(t, ends, 120, func(seat int, end *end) {
+		if seat == 1 && bumped < 0 && end.lock.Frame() >= 40 {
+			bumped = end.lock.Frame()
+			end.state++
+		}
+	})
+	for seat, end := range ends {
+		frame, desync := end.lock.Desync()
+		if !desync || frame <= bumped {
+			t.Errorf("seat %v found the desync after frame %v at %v, %v", seat, bumped, frame, desync)
+		}
+	}
+}
+
+func TestReadError(t *testing.T) {
+	conn, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// every read times out from now on
+	conn.socket.SetReadDeadline(time.Now())
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, packet := range conn.Poll() {
+			if _, ok := packet.Err.(ReadError); ok {
+				return
+			}
+			t.Fatalf("unexpected packet %+v", packet)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the read errors were never reported")
+}
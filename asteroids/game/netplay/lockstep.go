@@ -0,0 +1,192 @@
+package netplay
+
+import (
+	"time"
+)
+
+// Lockstep keeps two instances simulating the same frames with the same input.
+// A frame is only simulated once both players' input for it is known. Local
+// input is scheduled delay frames ahead so that, as long as packets take less
+// time than that to arrive, neither end has to wait.
+type Lockstep struct {
+	conn  *Conn
+	seat  int
+	delay int
+	// inputs of each seat by frame, the local seat's are all known up to
+	// delay frames ahead of next
+	inputs [2][]byte
+	next   int
+	acked  int
+	// checksums of simulated frames that the other end hasn't reported on yet,
+	// and the other end's that haven't been simulated here yet
+	sums     map[int]uint32
+	peerSums map[int]uint32
+	checked  int
+	lastSum  uint32
+	desync   int
+	heard    time.Time
+	left     bool
+	err      error
+}
+
+// NewLockstep starts a lockstep session on a connection that already has a
+// peer. seat is 0 on the host and 1 on the joiner. The first delay frames have
+// no input from either player.
+func NewLockstep(conn *Conn, seat, delay int) *Lockstep {
+	lock := &Lockstep{
+		conn:     conn,
+		seat:     seat,
+		delay:    delay,
+		sums:     map[int]uint32{},
+		peerSums: map[int]uint32{},
+		desync:   -1,
+		heard:    time.Now(),
+	}
+	for i := range lock.inputs {
+		lock.inputs[i] = make([]byte, delay)
+	}
+	return lock
+}
+
+// Push schedules the local player's input for the next free frame. It returns
+// false without doing anything if the local input is already delay frames
+// ahead of the simulation.
+func (lock *Lockstep) Push(input byte) bool {
+	local := lock.inputs[lock.seat]
+	if len(local) > lock.next+lock.delay {
+		return false
+	}
+	lock.inputs[lock.seat] = append(local, input)
+	return true
+}
+
+// Poll handles every packet that has arrived. Messages other than Inputs and Bye
+// are returned for the caller to deal with.
+func (lock *Lockstep) Poll() []Message {
+	other := []Message{}
+	for _, packet := range lock.conn.Poll() {
+		if err, ok := packet.Err.(ReadError); ok {
+			lock.err = err
+			continue
+		} else if packet.Err != nil {
+			continue
+		}
+		lock.heard = time.Now()
+		switch msg := packet.Message.(type) {
+		case Inputs:
+			lock.receive(msg)
+		case Bye:
+			lock.left = true
+		default:
+			other = append(other, msg)
+		}
+	}
+	return other
+}
+
+func (lock *Lockstep) receive(msg Inputs) {
+	peer := 1 - lock.seat
+	for i, input := range msg.Inputs {
+		if frame := int(msg.First) + i; frame == len(lock.inputs[peer]) {
+			lock.inputs[peer] = append(lock.inputs[peer], input)
+		}
+	}
+	if ack := int(msg.Ack); ack > lock.acked {
+		lock.acked = ack
+	}
+	if msg.Checked > 0 {
+		lock.peerSums[int(msg.Checked)] = msg.Checksum
+		lock.compare(int(msg.Checked))
+	}
+}
+
+// Next returns both players' input for the next frame in seat order, or false if
+// the other end's hasn't arrived yet.
+func (lock *Lockstep) Next() ([2]byte, bool) {
+	if lock.next >= len(lock.inputs[0]) || lock.next >= len(lock.inputs[1]) {
+		return [2]byte{}, false
+	}
+	inputs := [2]byte{lock.inputs[0][lock.next], lock.inputs[1][lock.next]}
+	lock.next++
+	return inputs, true
+}
+
+// Check records the checksum of the state after the frame Next last returned,
+// to be compared with the other end's.
+func (lock *Lockstep) Check(sum uint32) {
+	lock.checked, lock.lastSum = lock.next, sum
+	lock.sums[lock.checked] = sum
+	lock.compare(lock.checked)
+}
+
+// compare looks for a desync at frame once both ends have a checksum for it, and
+// forgets every checksum up to it.
+func (lock *Lockstep) compare(frame int) {
+	sum, ok := lock.sums[frame]
+	peerSum, peerOK := lock.peerSums[frame]
+	if !ok || !peerOK {
+		return
+	}
+	if sum != peerSum && lock.desync < 0 {
+		lock.desync = frame
+	}
+	for old := range lock.sums {
+		if old <= frame {
+			delete(lock.sums, old)
+		}
+	}
+	for old := range lock.peerSums {
+		if old <= frame {
+			delete(lock.peerSums, old)
+		}
+	}
+}
+
+// Flush sends the other end every local input it hasn't acknowledged, how many
+// of its inputs are known here, and the latest checksum.
+func (lock *Lockstep) Flush() error {
+	local := lock.inputs[lock.seat]
+	first := lock.acked
+	if first > len(local) {
+		first = len(local)
+	}
+	msg := Inputs{
+		First:    uint32(first),
+		Inputs:   local[first:],
+		Ack:      uint32(len(lock.inputs[1-lock.seat])),
+		Checked:  uint32(lock.checked),
+		Checksum: lock.lastSum,
+	}
+	return lock.conn.Send(msg)
+}
+
+// Frame is how many frames have been simulated.
+func (lock *Lockstep) Frame() int {
+	return lock.next
+}
+
+// Desync returns the first frame the two ends were found to disagree on.
+func (lock *Lockstep) Desync() (int, bool) {
+	return lock.desync, lock.desync >= 0
+}
+
+// Silent is how long it has been since anything was heard from the other end.
+func (lock *Lockstep) Silent() time.Duration {
+	return time.Since(lock.heard)
+}
+
+// Left reports if the other end said Bye.
+func (lock *Lockstep) Left() bool {
+	return lock.left
+}
+
+// Err is why the connection stopped working, if it has.
+func (lock *Lockstep) Err() error {
+	return lock.err
+}
+
+// Leave tells the other end this one is leaving and closes the connection.
+func (lock *Lockstep) Leave() {
+	lock.conn.Send(Bye{})
+	lock.conn.Close()
+}
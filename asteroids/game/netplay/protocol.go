@@ -0,0 +1,231 @@
+// Package netplay is the network session for two player asteroids. Both
+// instances run the same deterministic simulation in lockstep, so the only
+// thing sent each frame is the input of the player at that end.
+//
+// Every packet is a single UDP datagram laid out as
+//
+//	magic    2 bytes  "AS"
+//	version  1 byte   Version
+//	type     1 byte   one of the message types below
+//	body     the message, integers big endian
+//
+// The header and the Reject message never change between versions so that an
+// instance can always tell the other end why it won't play with it.
+//
+//	Hello    joiner to host, resent until welcomed
+//	         rules uint32, ship uint8
+//	Welcome  host to joiner, sent for every Hello
+//	         rules uint32, seed int64, mode uint8, flags uint8 (1 is versus),
+//	         delay uint8, ships 2 x uint8 in seat order
+//	Reject   either way, reason as uint8 length then that many bytes
+//	Inputs   either way, every frame
+//	         first uint32, count uint8, count x input uint8,
+//	         ack uint32, checked uint32, checksum uint32
+//	Bye      either way, no body, the sender has left
+//
+// rules is a hash of the ship and tuning data and the architecture, which both
+// ends need to agree on to simulate the same thing. The architecture is in it
+// because float results are only the same on both ends if they run on the same
+// one, so only instances on the same architecture can play together. Inputs carries every input of the sender from
+// frame first on that the other end hasn't acknowledged yet, so lost packets
+// are covered by the next one. ack is how many of the other end's inputs the
+// sender has, and checked is how many frames it has simulated, with checksum
+// being the state after the last of them, 0 frames meaning none.
+package netplay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Version is bumped whenever the packet layout or the meaning of a message
+// changes.
+const Version = 2
+
+// maxInputs is the most inputs that fit in one Inputs message.
+const maxInputs = 255
+
+var magic = [2]byte{'A', 'S'}
+
+type messageType uint8
+
+const (
+	typeHello messageType = iota + 1
+	typeWelcome
+	typeReject
+	typeInputs
+	typeBye
+)
+
+var (
+	errNotOurs    = errors.New("not a netplay packet")
+	errBadMessage = errors.New("malformed packet")
+)
+
+// VersionError is returned when a packet comes from an instance speaking a
+// different version of the protocol.
+type VersionError struct {
+	Version uint8
+}
+
+func (err VersionError) Error() string {
+	return fmt.Sprintf("other end speaks protocol version %v, this one speaks %v", err.Version, Version)
+}
+
+// Message is one of Hello, Welcome, Reject, Inputs or Bye.
+type Message interface {
+	messageType() messageType
+}
+
+// Hello asks the host to start a round.
+type Hello struct {
+	Rules uint32
+	Ship  uint8
+}
+
+// Welcome starts a round, telling the joiner everything it needs to simulate it
+// exactly like the host.
+type Welcome struct {
+	Rules  uint32
+	Seed   int64
+	Mode   uint8
+	Versus bool
+	Delay  uint8
+	Ships  [2]uint8
+}
+
+// Reject turns a Hello down.
+type Reject struct {
+	Reason string
+}
+
+// Inputs carries the sender's unacknowledged inputs and its latest checksum.
+type Inputs struct {
+	First    uint32
+	Inputs   []byte
+	Ack      uint32
+	Checked  uint32
+	Checksum uint32
+}
+
+// Bye says the sender has left the round.
+type Bye struct{}
+
+func (Hello) messageType() messageType   { return typeHello }
+func (Welcome) messageType() messageType { return typeWelcome }
+func (Reject) messageType() messageType  { return typeReject }
+func (Inputs) messageType() messageType  { return typeInputs }
+func (Bye) messageType() messageType     { return typeBye }
+
+// Encode lays the message out as a packet.
+func Encode(msg Message) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(magic[:])
+	buf.WriteByte(Version)
+	buf.WriteByte(byte(msg.messageType()))
+	put := func(v interface{}) { binary.Write(buf, binary.BigEndian, v) }
+	switch msg := msg.(type) {
+	case Hello:
+		put(msg.Rules)
+		put(msg.Ship)
+	case Welcome:
+		flags := uint8(0)
+		if msg.Versus {
+			flags |= 1
+		}
+		put(msg.Rules)
+		put(msg.Seed)
+		put(msg.Mode)
+		put(flags)
+		put(msg.Delay)
+		put(msg.Ships)
+	case Reject:
+		reason := msg.Reason
+		if len(reason) > 255 {
+			reason = reason[:255]
+		}
+		buf.WriteByte(uint8(len(reason)))
+		buf.WriteString(reason)
+	case Inputs:
+		inputs := msg.Inputs
+		if len(inputs) > maxInputs {
+			inputs = inputs[:maxInputs]
+		}
+		put(msg.First)
+		buf.WriteByte(uint8(len(inputs)))
+		buf.Write(inputs)
+		put(msg.Ack)
+		put(msg.Checked)
+		put(msg.Checksum)
+	}
+	return buf.Bytes()
+}
+
+// Decode reads a packet. Packets from another version of the protocol give a
+// VersionError, unless they are a Reject which is decoded all the same.
+func Decode(packet []byte) (Message, error) {
+	if len(packet) < 4 || packet[0] != magic[0] || packet[1] != magic[1] {
+		return nil, errNotOurs
+	}
+	version, kind := packet[2], messageType(packet[3])
+	buf := bytes.NewReader(packet[4:])
+	if kind == typeReject {
+		size, err := buf.ReadByte()
+		if err != nil || buf.Len() < int(size) {
+			return nil, errBadMessage
+		}
+		reason := make([]byte, size)
+		buf.Read(reason)
+		return Reject{Reason: string(reason)}, nil
+	}
+	if version != Version {
+		return nil, VersionError{Version: version}
+	}
+
+	var err error
+	get := func(v interface{}) {
+		if err == nil {
+			err = binary.Read(buf, binary.BigEndian, v)
+		}
+	}
+	var msg Message
+	switch kind {
+	case typeHello:
+		hello := Hello{}
+		get(&hello.Rules)
+		get(&hello.Ship)
+		msg = hello
+	case typeWelcome:
+		welcome := Welcome{}
+		var flags uint8
+		get(&welcome.Rules)
+		get(&welcome.Seed)
+		get(&welcome.Mode)
+		get(&flags)
+		get(&welcome.Delay)
+		get(&welcome.Ships)
+		welcome.Versus = flags&1 != 0
+		msg = welcome
+	case typeInputs:
+		inputs := Inputs{}
+		var count uint8
+		get(&inputs.First)
+		get(&count)
+		inputs.Inputs = make([]byte, count)
+		get(inputs.Inputs)
+		get(&inputs.Ack)
+		get(&inputs.Checked)
+		get(&inputs.Checksum)
+		msg = inputs
+	case typeBye:
+		msg = Bye{}
+	default:
+		return nil, errBadMessage
+	}
+	if err != nil {
+		return nil, errBadMessage
+	}
+	return msg, nil
+}
@@ -0,0 +1,33 @@
+package particles
+
+// Color is a colour with channels from 0 to 255, the same as the renderer uses.
+type Color struct {
+	R, G, B, A float32
+}
+
+// Ramp is a list of colours a particle fades through, evenly spaced over its
+// life. An empty ramp is plain white.
+type Ramp []Color
+
+// At returns the colour a particle t of the way through its life, from 0 to 1,
+// should be.
+func (ramp Ramp) At(t float32) Color {
+	switch {
+	case len(ramp) == 0:
+		return Color{R: 255, G: 255, B: 255, A: 255}
+	case len(ramp) == 1 || t <= 0:
+		return ramp[0]
+	case t >= 1:
+		return ramp[len(ramp)-1]
+	}
+	pos := t * float32(len(ramp)-1)
+	i := int(pos)
+	f := pos - float32(i)
+	a, b := ramp[i], ramp[i+1]
+	return Color{
+		R: a.R + (b.R-a.R)*f,
+		G: a.G + (b.G-a.G)*f,
+		B: a.B + (b.B-a.B)*f,
+		A: a.A + (b.A-a.A)*f,
+	}
+}
@@ -0,0 +1,122 @@
+package particles
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Config is what an emitter throws out. Angles follow the same convention as
+// sprites, 0 is straight up and they grow clockwise.
+type Config struct {
+	// Rate is how many particles are emitted a second while emitting.
+	Rate float32
+	// LifeMin and LifeMax bound how many seconds each particle lives.
+	LifeMin, LifeMax float32
+	// SpeedMin and SpeedMax bound how fast each particle leaves the emitter.
+	SpeedMin, SpeedMax float32
+	// Spread is how far either side of the emitter's direction particles can
+	// head, a spread of Pi throws them out in every direction.
+	Spread float32
+	// Drag is the fraction of its speed a particle loses every second.
+	Drag float32
+	// Inherit is how much of the emitter's velocity particles keep.
+	Inherit float32
+	// Colors is what particles fade through over their life.
+	Colors Ramp
+}
+
+// Particle is a single point thrown out by an emitter.
+type Particle struct {
+	X, Y   float32
+	VX, VY float32
+	Age    float32
+	Life   float32
+}
+
+// Emitter simulates a set of particles. It has no idea how they are drawn, so it
+// can be run and inspected without a window.
+type Emitter struct {
+	Config
+	X, Y      float32
+	VX, VY    float32
+	Direction float32
+	// Emitting is whether particles are being thrown out at Rate.
+	Emitting  bool
+	particles []Particle
+	pending   float32
+	rng       *rand.Rand
+}
+
+func NewEmitter(config Config, rng *rand.Rand) *Emitter {
+	return &Emitter{
+		Config:    config,
+		particles: []Particle{},
+		rng:       rng,
+	}
+}
+
+// Burst throws out count particles at once.
+func (emitter *Emitter) Burst(count int) {
+	for i := 0; i < count; i++ {
+		emitter.emit()
+	}
+}
+
+func (emitter *Emitter) emit() {
+	angle := float64(emitter.Direction + emitter.between(-emitter.Spread, emitter.Spread))
+	speed := emitter.between(emitter.SpeedMin, emitter.SpeedMax)
+	emitter.particles = append(emitter.particles, Particle{
+		X:    emitter.X,
+		Y:    emitter.Y,
+		VX:   float32(math.Sin(angle))*speed + emitter.VX*emitter.Inherit,
+		VY:   -float32(math.Cos(angle))*speed + emitter.VY*emitter.Inherit,
+		Life: emitter.between(emitter.LifeMin, emitter.LifeMax),
+	})
+}
+
+func (emitter *Emitter) between(min, max float32) float32 {
+	return min + emitter.rng.Float32()*(max-min)
+}
+
+// Update emits new particles if the emitter is on and moves, slows and ages the
+// ones already out, dropping those that have lived out their life.
+func (emitter *Emitter) Update(dt float32) {
+	if emitter.Emitting {
+		emitter.pending += emitter.Rate * dt
+		for ; emitter.pending >= 1; emitter.pending-- {
+			emitter.emit()
+		}
+	} else {
+		emitter.pending = 0
+	}
+
+	drag := float32(math.Max(0, float64(1-emitter.Drag*dt)))
+	alive := emitter.particles[:0]
+	for _, particle := range emitter.particles {
+		particle.Age += dt
+		if particle.Age >= particle.Life {
+			continue
+		}
+		particle.VX, particle.VY = particle.VX*drag, particle.VY*drag
+		particle.X, particle.Y = particle.X+particle.VX*dt, particle.Y+particle.VY*dt
+		alive = append(alive, particle)
+	}
+	emitter.particles = alive
+}
+
+// Each calls fn with every live particle and the colour it currently is.
+func (emitter *Emitter) Each(fn func(particle Particle, color Color)) {
+	for _, particle := range emitter.particles {
+		fn(particle, emitter.Colors.At(particle.Age/particle.Life))
+	}
+}
+
+// Len is how many particles are alive.
+func (emitter *Emitter) Len() int {
+	return len(emitter.particles)
+}
+
+// Done is true once the emitter is off and all its particles have died.
+func (emitter *Emitter) Done() bool {
+	return !emitter.Emitting && len(emitter.particles) == 0
+}
@@ -0,0 +1,107 @@
+package particles
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const step = 1.0 / 60
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func newTestEmitter(config Config) *Emitter {
+	return NewEmitter(config, rand.New(rand.NewSource(1)))
+}
+
+func TestEmit(t *testing.T) {
+	emitter := newTestEmitter(Config{Rate: 30, LifeMin: 2, LifeMax: 2, SpeedMin: 10, SpeedMax: 20, Spread: 0.5})
+	emitter.X, emitter.Y = 100, 50
+	emitter.Burst(5)
+	if emitter.Len() != 5 {
+		t.Fatalf("burst of 5 gave %v particles", emitter.Len())
+	}
+
+	emitter.Emitting = true
+	for i := 0; i < 60; i++ {
+		emitter.Update(step)
+	}
+	if got := emitter.Len(); got < 34 || got > 35 {
+		t.Errorf("30 a second for a second after a burst of 5 gave %v particles", got)
+	}
+	// particles head up within the spread, at a speed within the bounds
+	emitter.Each(func(particle Particle, color Color) {
+		speed := float32(math.Hypot(float64(particle.VX), float64(particle.VY)))
+		if speed < 10-1e-3 || speed > 20+1e-3 {
+			t.Errorf("speed %v is out of bounds", speed)
+		}
+		if angle := math.Atan2(float64(particle.VX), float64(-particle.VY)); math.Abs(angle) > 0.5+1e-3 {
+			t.Errorf("heading %v is outside of the spread", angle)
+		}
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	emitter := newTestEmitter(Config{
+		LifeMin: 1, LifeMax: 1,
+		SpeedMin: 10, SpeedMax: 10,
+		Drag:    0.5,
+		Inherit: 1,
+		Colors:  Ramp{{R: 0, A: 255}, {R: 200, A: 0}},
+	})
+	emitter.VX = 5
+	emitter.Burst(1)
+	emitter.Update(0.5)
+	emitter.Each(func(particle Particle, color Color) {
+		// straight up at 10 plus the emitter's 5 to the right, slowed by a
+		// quarter
+		if !near(particle.VX, 3.75) || !near(particle.VY, -7.5) {
+			t.Errorf("velocity %v, %v", particle.VX, particle.VY)
+		}
+		if !near(particle.X, 1.875) || !near(particle.Y, -3.75) {
+			t.Errorf("position %v, %v", particle.X, particle.Y)
+		}
+		if !near(color.R, 100) || !near(color.A, 127.5) {
+			t.Errorf("half way through its life the colour is %+v", color)
+		}
+	})
+}
+
+func TestExpiry(t *testing.T) {
+	emitter := newTestEmitter(Config{Rate: 60, LifeMin: 1, LifeMax: 1})
+	emitter.Emitting = true
+	for i := 0; i < 30; i++ {
+		emitter.Update(step)
+	}
+	if emitter.Done() {
+		t.Fatal("done while emitting")
+	}
+	emitter.Emitting = false
+	for i := 0; i < 55; i++ {
+		emitter.Update(step)
+	}
+	if emitter.Done() {
+		t.Fatal("done before the last particle died")
+	}
+	for i := 0; i < 5; i++ {
+		emitter.Update(step)
+	}
+	if !emitter.Done() || emitter.Len() != 0 {
+		t.Errorf("%v particles left after their life", emitter.Len())
+	}
+}
+
+func TestReuse(t *testing.T) {
+	emitter := newTestEmitter(Config{Rate: 120, LifeMin: 0.5, LifeMax: 1, SpeedMin: 10, SpeedMax: 50})
+	emitter.Emitting = true
+	for i := 0; i < 120; i++ {
+		emitter.Update(step)
+	}
+	// dead particles make room for new ones so a steady stream settles on a
+	// buffer and stays there
+	if allocs := testing.AllocsPerRun(600, func() { emitter.Update(step) }); allocs != 0 {
+		t.Errorf("%v allocations per update", allocs)
+	}
+}
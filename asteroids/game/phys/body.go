@@ -1,104 +1,248 @@
 package phys
 
-import (
-	"github.com/tanema/amore/gfx"
-)
-
 type Body struct {
-	world      *World
-	Name       string
-	inital     []float32
-	points     []float32
-	cells      []*Cell
-	Collidable Collidable
+	world                  *World
+	id                     uint64
+	Name                   string
+	Category               Category
+	Mask                   Category
+	parts                  []part
+	minX, minY, maxX, maxY float32
+	cells                  []cellSlot
+	cellRange              [4]int
+	wraps                  bool
+	swept                  bool
+	overhangIndex          int
+	Collidable             Collidable
+	// kept between moves so that moving doesn't allocate once warmed up
+	mat       Matrix
+	contacts  []*Contact
+	pool      []*Contact
+	scratch   Contact
+	testedAt  uint64
+	touchedAt uint64
 }
 
 type Collidable interface {
 	Destroy(force bool)
 }
 
-func newBody(world *World, collidable Collidable, name string, points []float32) *Body {
-	return &Body{
+func newBody(world *World, id uint64, collidable Collidable, name string, shapes []Shape) *Body {
+	body := &Body{
 		Name:       name,
 		world:      world,
+		id:         id,
+		Category:   CategoryDefault,
+		Mask:       CategoryAll,
 		Collidable: collidable,
-		inital:     points,
-		points:     make([]float32, len(points)),
+		parts:      make([]part, len(shapes)),
+	}
+	for i, shape := range shapes {
+		body.parts[i] = newPart(shape)
 	}
+	return body
 }
 
-func (body *Body) Move(x, y, rot, scale float32) []*Body {
-	if body.Collidable != nil {
-		for _, cell := range body.cells {
-			cell.leave(body)
+// newProbe creates a body that is never added to the world, used to run the
+// narrow phase against arbitrary shapes when querying. The shapes are already
+// where they are in the world.
+func newProbe(shapes ...Shape) *Body {
+	probe := &Body{parts: make([]part, len(shapes))}
+	for i, shape := range shapes {
+		probe.parts[i] = part{shape: shape, points: shape.Points, radius: shape.Radius}
+		probe.parts[i].updateBounds()
+	}
+	probe.updateBounds()
+	return probe
+}
+
+// Move transforms the body to its new position and returns everything it is
+// touching there. Handlers registered with OnBegin are called for any pair that
+// wasn't touching last step. The contacts are reused by the body's next move so
+// they must not be held on to past that.
+func (body *Body) Move(x, y, rot, scale float32) []*Contact {
+	body.place(x, y, rot, scale)
+	body.contacts, body.pool = body.contacts[:0], body.pool[:0]
+	if body.Collidable == nil {
+		return body.contacts
+	}
+
+	world := body.world
+	world.stamp++
+	touched := world.stamp
+	if body.swept {
+		body.sweep(touched)
+	}
+	body.findContacts(body, 0, 0, touched)
+	var offsets [3][2]float32
+	for _, offset := range offsets[:body.ghostOffsets(&offsets)] {
+		body.findContacts(world.ghost(body, offset[0], offset[1]), offset[0], offset[1], touched)
+	}
+	for _, owner := range world.overhanging {
+		if owner == body || owner.touchedAt == touched || !body.collidesWith(owner) {
+			continue
+		}
+		for _, offset := range offsets[:owner.ghostOffsets(&offsets)] {
+			dx, dy := offset[0], offset[1]
+			if !owner.overlapsBounds(body.minX-dx, body.minY-dy, body.maxX-dx, body.maxY-dy) {
+				continue
+			}
+			if collide(body, world.ghost(owner, dx, dy), &body.scratch) {
+				owner.touchedAt = touched
+				body.scratch.Body = owner
+				body.keep(body.scratch)
+				break
+			}
 		}
 	}
 
-	mat := &Matrix{}
-	mat.translate(x, y, rot, scale)
-	for i := 0; i < len(body.inital); i += 2 {
-		body.points[i], body.points[i+1] = mat.multiply(body.inital[i], body.inital[i+1])
+	// handlers may remove bodies so they are only called once the cells are no
+	// longer being walked, earliest impact first
+	sortContacts(body.contacts)
+	for _, contact := range body.contacts {
+		world.begin(body, contact)
 	}
 
-	if body.Collidable != nil {
-		body.cells = []*Cell{}
-		others := []*Body{}
-		others_map := map[*Body]bool{}
-		for i := 0; i < len(body.points); i += 2 {
-			cell := body.world.CellAt(body.points[i], body.points[i+1])
+	return body.contacts
+}
 
+// findContacts runs the narrow phase between shape, which is either the body or
+// one of its ghosts offset by dx, dy, and everything in the cells it covers.
+// Contact points are moved back next to the body itself.
+func (body *Body) findContacts(shape *Body, dx, dy float32, touched uint64) {
+	world := body.world
+	world.stamp++
+	tested := world.stamp
+	for x := world.cellCoord(shape.minX); x <= world.cellCoord(shape.maxX); x++ {
+		for y := world.cellCoord(shape.minY); y <= world.cellCoord(shape.maxY); y++ {
+			cell, ok := world.cells[cellKey{x: x, y: y}]
+			if !ok {
+				continue
+			}
 			for _, other := range cell.bodies {
-				if other != body && other.pointInside(body.points[i], body.points[i+1]) {
-					if _, ok := others_map[other]; !ok {
-						others = append(others, other)
-					}
-					others_map[other] = true
+				if other == body || other.touchedAt == touched || other.testedAt == tested {
+					continue
+				}
+				other.testedAt = tested
+				if !body.collidesWith(other) || !other.overlapsBounds(shape.minX, shape.minY, shape.maxX, shape.maxY) {
+					continue
+				}
+				if collide(shape, other, &body.scratch) {
+					other.touchedAt = touched
+					body.scratch.X, body.scratch.Y = body.scratch.X-dx, body.scratch.Y-dy
+					body.keep(body.scratch)
 				}
 			}
+		}
+	}
+}
 
-			body.cells = append(body.cells, cell)
-			cell.enter(body)
+// keep copies a contact into one the body owns and adds it to this move's
+// contacts. The copies are recycled from move to move.
+func (body *Body) keep(contact Contact) {
+	if len(body.pool) == cap(body.pool) {
+		body.pool = append(body.pool, nil)
+	} else {
+		body.pool = body.pool[:len(body.pool)+1]
+	}
+	kept := body.pool[len(body.pool)-1]
+	if kept == nil {
+		kept = &Contact{}
+		body.pool[len(body.pool)-1] = kept
+	}
+	*kept = contact
+	body.contacts = append(body.contacts, kept)
+}
+
+// sortContacts orders contacts by time of impact. It is an insertion sort as
+// there are only ever a few, and unlike sort.SliceStable it doesn't allocate.
+func sortContacts(contacts []*Contact) {
+	for i := 1; i < len(contacts); i++ {
+		for j := i; j > 0 && contacts[j].Time < contacts[j-1].Time; j-- {
+			contacts[j], contacts[j-1] = contacts[j-1], contacts[j]
 		}
+	}
+}
 
-		return others
+// place transforms the body's points and re-hashes it without looking for
+// contacts.
+func (body *Body) place(x, y, rot, scale float32) {
+	if body.swept {
+		body.remember()
+	}
+	body.mat.translate(x, y, rot, scale)
+	for i := range body.parts {
+		body.parts[i].place(&body.mat)
 	}
+	body.updateBounds()
+	if body.Collidable != nil {
+		body.world.update(body)
+		body.world.updateOverhang(body)
+	}
+}
 
-	return []*Body{}
+// updateBounds sets the body's bounds to cover the bounds of all of its parts,
+// which must be up to date.
+func (body *Body) updateBounds() {
+	if len(body.parts) == 0 {
+		return
+	}
+	first := &body.parts[0]
+	body.minX, body.minY, body.maxX, body.maxY = first.minX, first.minY, first.maxX, first.maxY
+	for i := range body.parts[1:] {
+		part := &body.parts[i+1]
+		body.minX, body.maxX = min(body.minX, part.minX), max(body.maxX, part.maxX)
+		body.minY, body.maxY = min(body.minY, part.minY), max(body.maxY, part.maxY)
+	}
 }
 
-func (body *Body) pointInside(x, y float32) bool {
-	j := 2
-	oddNodes := false
-	for i := 0; i < len(body.points); i += 2 {
-		y0 := body.points[i+1]
-		y1 := body.points[j+1]
-		if (y0 < y && y1 >= y) || (y1 < y && y0 >= y) {
-			if body.points[i]+(y-y0)/(y1-y0)*(body.points[j]-body.points[i]) < x {
-				oddNodes = !oddNodes
-			}
+func (body *Body) overlapsBounds(minX, minY, maxX, maxY float32) bool {
+	return body.minX <= maxX && body.maxX >= minX && body.minY <= maxY && body.maxY >= minY
+}
+
+// GetBounds returns the axis aligned bounding box of the transformed outline.
+func (body *Body) GetBounds() (float32, float32, float32, float32) {
+	return body.minX, body.minY, body.maxX, body.maxY
+}
+
+func (body *Body) leaveCells() {
+	for len(body.cells) > 0 {
+		last := len(body.cells) - 1
+		slot := body.cells[last]
+		body.cells = body.cells[:last]
+		slot.cell.leave(slot.index)
+		if len(slot.cell.bodies) == 0 {
+			body.world.drop(slot.cell)
 		}
-		j += 2
-		if j == len(body.points) {
-			j = 0
+	}
+}
+
+func (body *Body) pointInside(x, y float32) bool {
+	for i := range body.parts {
+		if body.parts[i].pointInside(x, y) {
+			return true
 		}
 	}
-	return oddNodes
+	return false
 }
 
+// GetPoints returns the transformed outline of the body's first shape, or the
+// center of it if it is a circle. EachShape has all of them.
 func (body *Body) GetPoints() []float32 {
-	return body.points
+	if len(body.parts) == 0 {
+		return nil
+	}
+	return body.parts[0].points
 }
 
 func (body *Body) Remove() {
-	for _, cell := range body.cells {
-		cell.leave(body)
-	}
+	body.world.remove(body)
 }
 
-func (body *Body) Draw() {
-	gfx.SetColor(255, 0, 0, 100)
-	for _, cell := range body.cells {
-		gfx.Rect(gfx.LINE, cell.x, cell.y, cell.width, cell.height)
+func (body *Body) Draw(canvas Canvas) {
+	canvas.SetColor(255, 0, 0, 100)
+	for _, slot := range body.cells {
+		canvas.Rect(slot.cell.x, slot.cell.y, slot.cell.width, slot.cell.height)
 	}
-	gfx.SetColor(255, 255, 255, 255)
+	canvas.SetColor(255, 255, 255, 255)
 }
@@ -0,0 +1,154 @@
+package phys
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestMove(t *testing.T) {
+	world := NewWorld(800, 600, 60)
+	a := world.AddBody(nop{}, "a", 100, 100, 1, square)
+	b := world.AddBody(nop{}, "b", 300, 100, 1, square)
+	if contacts := a.Move(115, 100, 0, 1); len(contacts) != 0 {
+		t.Errorf("a moved clear of b but touched %v", contacts)
+	}
+	contacts := a.Move(285, 100, 0, 1)
+	if len(contacts) != 1 || contacts[0].Body != b {
+		t.Fatalf("a moved onto b but touched %v", contacts)
+	}
+	if contact := contacts[0]; contact.Depth != 5 || contact.NormalX != -1 || contact.NormalY != 0 {
+		t.Errorf("contact %+v", *contact)
+	}
+}
+
+// steady makes a world with bodies of every kind: plain, wrapping, swept and
+// round, with a handler for every pair.
+func steady(count int) (*World, []*Body) {
+	world := NewWorld(800, 600, 60)
+	world.OnBegin(CategoryAll, CategoryAll, func(a, b *Body, contact *Contact) {})
+	rng := rand.New(rand.NewSource(1))
+	bodies := make([]*Body, count)
+	for i := range bodies {
+		x, y := rng.Float32()*800, rng.Float32()*600
+		if i%4 == 3 {
+			bodies[i] = world.AddShapes(nop{}, "round", x, y, 1, Circle(0, 0, 10))
+		} else {
+			bodies[i] = world.AddBody(nop{}, "square", x, y, 1, square)
+		}
+		bodies[i].SetWraps(i%2 == 0)
+		bodies[i].SetSwept(i%3 == 0)
+	}
+	return world, bodies
+}
+
+// step moves every body a little, so that they cross cells, wrap and run into
+// each other, and then steps the world.
+func step(world *World, bodies []*Body, frame int) {
+	for i, body := range bodies {
+		x := float32((i*37+frame*3)%800) - 5
+		y := float32((i*53+frame*2)%600) - 5
+		body.Move(x, y, float32(frame)*0.01, 1)
+	}
+	world.Step()
+}
+
+func TestMoveDoesNotAllocate(t *testing.T) {
+	t.Run("in place", func(t *testing.T) {
+		world := NewWorld(800, 600, 60)
+		body := world.AddBody(nop{}, "body", 100, 100, 1, square)
+		body.Move(101, 100, 0, 1)
+		if allocs := testing.AllocsPerRun(100, func() { body.Move(101, 100, 0.1, 1) }); allocs != 0 {
+			t.Errorf("%v allocations per move", allocs)
+		}
+	})
+
+	t.Run("changing cells", func(t *testing.T) {
+		world := NewWorld(800, 600, 60)
+		body := world.AddBody(nop{}, "body", 50, 50, 1, square)
+		// over the border at 60 and back, so the body swaps cells every move
+		x := float32(50)
+		move := func() {
+			x = 130 - x
+			body.Move(x, 50, 0, 1)
+		}
+		move()
+		move()
+		before := body.cellRange
+		move()
+		if body.cellRange == before {
+			t.Fatal("the body didn't change cells")
+		}
+		if allocs := testing.AllocsPerRun(100, move); allocs != 0 {
+			t.Errorf("%v allocations per move", allocs)
+		}
+	})
+
+	t.Run("with contacts", func(t *testing.T) {
+		world := NewWorld(800, 600, 60)
+		world.OnBegin(CategoryAll, CategoryAll, func(a, b *Body, contact *Contact) {})
+		for _, x := range []float32{90, 110, 100} {
+			world.AddBody(nop{}, "other", x, 110, 1, square)
+		}
+		body := world.AddBody(nop{}, "body", 100, 100, 1, square)
+		body.SetSwept(true)
+		touched := 0
+		move := func() {
+			touched = len(body.Move(100, 100, 0, 1))
+			world.Step()
+		}
+		move()
+		if touched != 3 {
+			t.Fatalf("the body touched %v bodies", touched)
+		}
+		if allocs := testing.AllocsPerRun(100, move); allocs != 0 {
+			t.Errorf("%v allocations per move", allocs)
+		}
+	})
+
+	t.Run("crowd", func(t *testing.T) {
+		world, bodies := steady(300)
+		frame := 0
+		move := func() {
+			frame++
+			step(world, bodies, frame)
+		}
+		for i := 0; i < 2000; i++ {
+			move()
+		}
+		if allocs := testing.AllocsPerRun(200, move); allocs != 0 {
+			t.Errorf("%v allocations per step of %v bodies", allocs, len(bodies))
+		}
+	})
+}
+
+func BenchmarkMove(b *testing.B) {
+	b.Run("steady", func(b *testing.B) {
+		world, bodies := steady(300)
+		for frame := 0; frame < 2000; frame++ {
+			step(world, bodies, frame)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			step(world, bodies, i)
+		}
+	})
+	// bodies spread at the same density whatever the count, to show the cost of
+	// a move doesn't grow with the world
+	for _, count := range []int{1000, 10000} {
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			world, bodies := crowd(count)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				body := bodies[i%count]
+				x, y := body.GetPoints()[0]+10, body.GetPoints()[1]+10
+				body.Move(x+float32(i%3-1), y+float32(i%5-2), 0, 1)
+				if i%count == 0 {
+					world.Step()
+				}
+			}
+		})
+	}
+}
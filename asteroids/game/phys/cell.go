@@ -1,20 +1,39 @@
 package phys
 
+type cellKey struct {
+	x, y int
+}
+
 type Cell struct {
+	key                 cellKey
 	x, y, width, height float32
 	bodies              []*Body
+	// slots[i] is where this cell is in bodies[i].cells
+	slots []int
+}
+
+// cellSlot records where a body sits inside one of its cells so that leaving
+// the cell doesn't have to search for it.
+type cellSlot struct {
+	cell  *Cell
+	index int
 }
 
 func (cell *Cell) enter(body *Body) {
-	cell.leave(body) // make sure this body is uniqe
+	cell.slots = append(cell.slots, len(body.cells))
+	body.cells = append(body.cells, cellSlot{cell: cell, index: len(cell.bodies)})
 	cell.bodies = append(cell.bodies, body)
 }
 
-func (cell *Cell) leave(body *Body) {
-	for i, b := range cell.bodies {
-		if body == b {
-			cell.bodies = append(cell.bodies[:i], cell.bodies[i+1:]...)
-			return
-		}
+// leave swaps the last body of the cell into the leaving body's place and lets
+// that body know its new index, so neither side has to be searched.
+func (cell *Cell) leave(index int) {
+	last := len(cell.bodies) - 1
+	moved, slot := cell.bodies[last], cell.slots[last]
+	cell.bodies[index], cell.slots[index] = moved, slot
+	cell.bodies[last] = nil
+	cell.bodies, cell.slots = cell.bodies[:last], cell.slots[:last]
+	if index != last {
+		moved.cells[slot].index = index
 	}
 }
@@ -0,0 +1,98 @@
+package phys
+
+import (
+	"math"
+)
+
+// collideCircles overlaps two round parts. The contact point is on b's rim.
+func collideCircles(a, b *part, contact *Contact) bool {
+	dx, dy := a.points[0]-b.points[0], a.points[1]-b.points[1]
+	reach := a.radius + b.radius
+	distance := float32(math.Sqrt(float64(dx*dx + dy*dy)))
+	if distance > reach {
+		return false
+	}
+	nx, ny, ok := normalize(dx, dy)
+	if !ok {
+		// dead center, any way out is as good as the other
+		nx, ny = 0, -1
+	}
+	*contact = Contact{
+		Depth:   reach - distance,
+		NormalX: nx,
+		NormalY: ny,
+		X:       b.points[0] + nx*b.radius,
+		Y:       b.points[1] + ny*b.radius,
+		Time:    1,
+	}
+	return true
+}
+
+// collideCircle overlaps a round part with an outline, the normal pointing from
+// the outline towards the circle's center and the contact point on the outline
+// closest to it.
+func collideCircle(circle, outline *part, contact *Contact) bool {
+	x, y := circle.points[0], circle.points[1]
+	distance := float32(math.MaxFloat32)
+	var px, py float32
+	outline.eachEdge(func(ax, ay, bx, by float32) {
+		cx, cy := closestOnSegment(x, y, ax, ay, bx, by)
+		if d := float32(math.Sqrt(float64((x-cx)*(x-cx) + (y-cy)*(y-cy)))); d < distance {
+			distance, px, py = d, cx, cy
+		}
+	})
+	inside := outline.pointInside(x, y)
+	if !inside && distance > circle.radius {
+		return false
+	}
+
+	nx, ny, ok := normalize(x-px, y-py)
+	if !ok {
+		// the center is right on the outline so go by the outline's middle
+		ox, oy := outline.center()
+		if nx, ny, ok = normalize(x-ox, y-oy); !ok {
+			nx, ny = 0, -1
+		}
+	}
+	depth := circle.radius - distance
+	if inside {
+		// the nearest edge is between the center and the way out
+		nx, ny = -nx, -ny
+		depth = circle.radius + distance
+	}
+	*contact = Contact{Depth: depth, NormalX: nx, NormalY: ny, X: px, Y: py, Time: 1}
+	return true
+}
+
+// closestOnSegment returns the point on a-b closest to p.
+func closestOnSegment(px, py, ax, ay, bx, by float32) (float32, float32) {
+	dx, dy := bx-ax, by-ay
+	t := float32(0)
+	if length := dx*dx + dy*dy; length > 0 {
+		t = ((px-ax)*dx + (py-ay)*dy) / length
+		t = float32(math.Max(0, math.Min(1, float64(t))))
+	}
+	return ax + t*dx, ay + t*dy
+}
+
+// circleTime returns how far along a-b, from 0 to 1, it enters the circle.
+// Segments that start inside never enter it.
+func circleTime(ax, ay, bx, by, cx, cy, radius float32) (float32, bool) {
+	dx, dy := bx-ax, by-ay
+	fx, fy := ax-cx, ay-cy
+	a := dx*dx + dy*dy
+	c := fx*fx + fy*fy - radius*radius
+	if a == 0 || c <= 0 {
+		return 0, false
+	}
+	b := 2 * (fx*dx + fy*dy)
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return 0, false
+	}
+	t := (-b - float32(math.Sqrt(float64(disc)))) / (2 * a)
+	if t < 0 || t > 1 {
+		return 0, false
+	}
+	return t, true
+}
@@ -0,0 +1,159 @@
+package phys
+
+import (
+	"math"
+)
+
+// Contact is a single overlap found between a moving body and another body.
+// The normal points away from the other body, towards the body that moved, and
+// Depth is how far the bodies have to be pushed apart along it to separate.
+// Time is how far through the move, from 0 to 1, the bodies first touched; only
+// swept bodies know this so every other contact is at 1.
+type Contact struct {
+	Body             *Body
+	Depth            float32
+	NormalX, NormalY float32
+	X, Y             float32
+	Time             float32
+}
+
+// collide runs the narrow phase between two bodies, part against part, and
+// keeps the deepest overlap as the contact between them. The result is written
+// to contact so that moving bodies can reuse one.
+func collide(body, other *Body, contact *Contact) bool {
+	found := false
+	var overlap Contact
+	for i := range body.parts {
+		a := &body.parts[i]
+		for j := range other.parts {
+			b := &other.parts[j]
+			if !a.overlaps(b) || !collideParts(a, b, &overlap) {
+				continue
+			}
+			if !found || overlap.Depth > contact.Depth {
+				*contact = overlap
+				found = true
+			}
+		}
+	}
+	contact.Body = other
+	return found
+}
+
+// collideParts picks the narrow phase for the kinds of the two parts. The
+// normal always points from b towards a.
+func collideParts(a, b *part, contact *Contact) bool {
+	switch {
+	case a.round() && b.round():
+		return collideCircles(a, b, contact)
+	case a.round():
+		return collideCircle(a, b, contact)
+	case b.round():
+		if !collideCircle(b, a, contact) {
+			return false
+		}
+		contact.NormalX, contact.NormalY = -contact.NormalX, -contact.NormalY
+		return true
+	}
+	return collideOutlines(a, b, contact)
+}
+
+// collideOutlines decides overlap with edge intersection and point containment
+// so concave outlines and two point segments are handled, then takes the depth
+// and normal from the axis of least penetration over both parts' edge normals.
+func collideOutlines(a, b *part, contact *Contact) bool {
+	var cx, cy float32
+	hits := 0
+
+	a.eachEdge(func(ax, ay, bx, by float32) {
+		b.eachEdge(func(cx0, cy0, cx1, cy1 float32) {
+			if x, y, ok := segmentIntersection(ax, ay, bx, by, cx0, cy0, cx1, cy1); ok {
+				cx, cy = cx+x, cy+y
+				hits++
+			}
+		})
+	})
+
+	if hits == 0 {
+		for i := 0; i < len(a.points); i += 2 {
+			if b.pointInside(a.points[i], a.points[i+1]) {
+				cx, cy = cx+a.points[i], cy+a.points[i+1]
+				hits++
+			}
+		}
+		for i := 0; i < len(b.points); i += 2 {
+			if a.pointInside(b.points[i], b.points[i+1]) {
+				cx, cy = cx+b.points[i], cy+b.points[i+1]
+				hits++
+			}
+		}
+	}
+
+	if hits == 0 {
+		return false
+	}
+
+	*contact = Contact{
+		Depth: float32(math.MaxFloat32),
+		X:     cx / float32(hits),
+		Y:     cy / float32(hits),
+		Time:  1,
+	}
+	testAxis := func(ax, ay, bx, by float32) {
+		nx, ny, ok := normalize(-(by - ay), bx-ax)
+		if !ok {
+			return
+		}
+		minA, maxA := a.project(nx, ny)
+		minB, maxB := b.project(nx, ny)
+		if depth := float32(math.Min(float64(maxA), float64(maxB)) - math.Max(float64(minA), float64(minB))); depth < contact.Depth {
+			contact.Depth, contact.NormalX, contact.NormalY = depth, nx, ny
+		}
+	}
+	a.eachEdge(testAxis)
+	b.eachEdge(testAxis)
+
+	if contact.Depth == float32(math.MaxFloat32) {
+		contact.Depth = 0
+	}
+
+	ax, ay := a.center()
+	bx, by := b.center()
+	if (ax-bx)*contact.NormalX+(ay-by)*contact.NormalY < 0 {
+		contact.NormalX, contact.NormalY = -contact.NormalX, -contact.NormalY
+	}
+
+	return true
+}
+
+func segmentIntersection(ax, ay, bx, by, cx, cy, dx, dy float32) (float32, float32, bool) {
+	t, ok := segmentTime(ax, ay, bx, by, cx, cy, dx, dy)
+	if !ok {
+		return 0, 0, false
+	}
+	return ax + t*(bx-ax), ay + t*(by-ay), true
+}
+
+// segmentTime returns how far along a-b, from 0 to 1, it crosses c-d.
+func segmentTime(ax, ay, bx, by, cx, cy, dx, dy float32) (float32, bool) {
+	rx, ry := bx-ax, by-ay
+	sx, sy := dx-cx, dy-cy
+	denom := rx*sy - ry*sx
+	if denom == 0 {
+		return 0, false
+	}
+	t := ((cx-ax)*sy - (cy-ay)*sx) / denom
+	u := ((cx-ax)*ry - (cy-ay)*rx) / denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+func normalize(x, y float32) (float32, float32, bool) {
+	length := float32(math.Sqrt(float64(x*x + y*y)))
+	if length == 0 {
+		return 0, 0, false
+	}
+	return x / length, y / length, true
+}
@@ -0,0 +1,90 @@
+package phys
+
+// Category is a bit set of collision categories. A body belongs to the
+// categories in its Category and only collides with bodies in its Mask.
+type Category uint32
+
+const (
+	CategoryDefault Category = 1
+	CategoryAll     Category = ^Category(0)
+)
+
+// Handler is called when two bodies start touching. The contact is from a's
+// point of view, contact.Body is b and the normal points from b towards a.
+type Handler func(a, b *Body, contact *Contact)
+
+type handlerEntry struct {
+	catA, catB Category
+	fn         Handler
+}
+
+type pairKey struct {
+	a, b uint64
+}
+
+// OnBegin registers fn to be called when a body in catA starts touching a body
+// in catB. The bodies are always passed to fn in that order. The contact is
+// reused once fn returns so it must be copied to be kept.
+func (world *World) OnBegin(catA, catB Category, fn Handler) {
+	world.handlers = append(world.handlers, handlerEntry{catA: catA, catB: catB, fn: fn})
+}
+
+// Step marks the end of a simulation step. Any pair of bodies that wasn't found
+// touching during the step is forgotten, so touching again later is a new begin.
+func (world *World) Step() {
+	for key, frame := range world.touching {
+		if frame < world.frame {
+			delete(world.touching, key)
+		}
+	}
+	world.frame++
+}
+
+// SetFilter sets the categories the body belongs to and those it collides with.
+func (body *Body) SetFilter(category, mask Category) {
+	body.Category, body.Mask = category, mask
+}
+
+func (body *Body) collidesWith(other *Body) bool {
+	return body.Mask&other.Category != 0 && other.Mask&body.Category != 0
+}
+
+func (body *Body) inWorld() bool {
+	return len(body.cells) > 0
+}
+
+// begin records that the body is touching contact.Body this step and calls the
+// matching handlers if they weren't already touching last step. Step forgets
+// every pair that wasn't touched, so any pair still recorded is ongoing.
+func (world *World) begin(body *Body, contact *Contact) {
+	other := contact.Body
+	key := pairKey{a: body.id, b: other.id}
+	if key.a > key.b {
+		key.a, key.b = key.b, key.a
+	}
+	_, touching := world.touching[key]
+	world.touching[key] = world.frame
+	if touching {
+		return
+	}
+
+	for _, handler := range world.handlers {
+		if !body.inWorld() || !other.inWorld() {
+			return
+		}
+		if body.Category&handler.catA != 0 && other.Category&handler.catB != 0 {
+			handler.fn(body, other, contact)
+		} else if other.Category&handler.catA != 0 && body.Category&handler.catB != 0 {
+			world.flipped = Contact{
+				Body:    body,
+				Depth:   contact.Depth,
+				NormalX: -contact.NormalX,
+				NormalY: -contact.NormalY,
+				X:       contact.X,
+				Y:       contact.Y,
+				Time:    contact.Time,
+			}
+			handler.fn(other, body, &world.flipped)
+		}
+	}
+}
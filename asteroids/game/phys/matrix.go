@@ -16,3 +16,9 @@ func (mat *Matrix) translate(x, y, rot, scale float32) {
 func (mat *Matrix) multiply(x, y float32) (float32, float32) {
 	return (mat[0] * x) + (mat[1] * y) + mat[2], (mat[3] * x) + (mat[4] * y) + mat[5]
 }
+
+// scale is how much the matrix stretches lengths, which is what the radius of a
+// circle grows by.
+func (mat *Matrix) scale() float32 {
+	return float32(math.Sqrt(float64(mat[0]*mat[0] + mat[3]*mat[3])))
+}
@@ -0,0 +1,145 @@
+package phys
+
+import (
+	"math"
+)
+
+// Shape is one part of a body in the body's own space, before it is moved. A
+// shape with a radius is a circle around its only point, anything else is an
+// outline: a segment if it has two points and a closed polygon if it has more.
+// Bodies made of several shapes collide as the union of them, so a concave ship
+// can be built out of convex parts and a round mine out of a single circle.
+type Shape struct {
+	Points []float32
+	Radius float32
+}
+
+// Polygon is a shape with the given outline.
+func Polygon(points ...float32) Shape {
+	return Shape{Points: points}
+}
+
+// Circle is a round shape centered on x, y.
+func Circle(x, y, radius float32) Shape {
+	return Shape{Points: []float32{x, y}, Radius: radius}
+}
+
+// part is a shape of a body as it was last placed in the world.
+type part struct {
+	shape                  Shape
+	points                 []float32
+	radius                 float32
+	prev                   []float32
+	minX, minY, maxX, maxY float32
+}
+
+func newPart(shape Shape) part {
+	return part{shape: shape, points: make([]float32, len(shape.Points)), radius: shape.Radius}
+}
+
+func (part *part) round() bool {
+	return part.radius > 0
+}
+
+// place transforms the shape's points by mat, scaling the radius of a circle
+// with it.
+func (part *part) place(mat *Matrix) {
+	for i := 0; i < len(part.shape.Points); i += 2 {
+		part.points[i], part.points[i+1] = mat.multiply(part.shape.Points[i], part.shape.Points[i+1])
+	}
+	part.radius = part.shape.Radius * mat.scale()
+	part.updateBounds()
+}
+
+func (part *part) updateBounds() {
+	if len(part.points) == 0 {
+		return
+	}
+	part.minX, part.minY = part.points[0], part.points[1]
+	part.maxX, part.maxY = part.points[0], part.points[1]
+	for i := 2; i < len(part.points); i += 2 {
+		part.minX, part.maxX = min(part.minX, part.points[i]), max(part.maxX, part.points[i])
+		part.minY, part.maxY = min(part.minY, part.points[i+1]), max(part.maxY, part.points[i+1])
+	}
+	part.minX, part.minY = part.minX-part.radius, part.minY-part.radius
+	part.maxX, part.maxY = part.maxX+part.radius, part.maxY+part.radius
+}
+
+func (part *part) overlaps(other *part) bool {
+	return part.minX <= other.maxX && part.maxX >= other.minX && part.minY <= other.maxY && part.maxY >= other.minY
+}
+
+// eachEdge calls fn with every edge of the part's transformed outline. A two
+// point outline is a single segment, anything larger is treated as a closed
+// polygon. Circles have no edges.
+func (part *part) eachEdge(fn func(ax, ay, bx, by float32)) {
+	if part.round() {
+		return
+	}
+	count := len(part.points)
+	for i := 0; i+3 < count; i += 2 {
+		fn(part.points[i], part.points[i+1], part.points[i+2], part.points[i+3])
+	}
+	if count > 4 {
+		fn(part.points[count-2], part.points[count-1], part.points[0], part.points[1])
+	}
+}
+
+func (part *part) project(nx, ny float32) (float32, float32) {
+	min := float32(math.MaxFloat32)
+	max := -float32(math.MaxFloat32)
+	for i := 0; i < len(part.points); i += 2 {
+		d := part.points[i]*nx + part.points[i+1]*ny
+		if d < min {
+			min = d
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return min - part.radius, max + part.radius
+}
+
+func (part *part) center() (float32, float32) {
+	var x, y float32
+	count := float32(len(part.points) / 2)
+	for i := 0; i < len(part.points); i += 2 {
+		x, y = x+part.points[i], y+part.points[i+1]
+	}
+	return x / count, y / count
+}
+
+func (part *part) pointInside(x, y float32) bool {
+	if part.round() {
+		dx, dy := x-part.points[0], y-part.points[1]
+		return dx*dx+dy*dy <= part.radius*part.radius
+	}
+	// a segment has no inside
+	if len(part.points) < 6 {
+		return false
+	}
+	j := 2
+	oddNodes := false
+	for i := 0; i < len(part.points); i += 2 {
+		y0 := part.points[i+1]
+		y1 := part.points[j+1]
+		if (y0 < y && y1 >= y) || (y1 < y && y0 >= y) {
+			if part.points[i]+(y-y0)/(y1-y0)*(part.points[j]-part.points[i]) < x {
+				oddNodes = !oddNodes
+			}
+		}
+		j += 2
+		if j == len(part.points) {
+			j = 0
+		}
+	}
+	return oddNodes
+}
+
+// EachShape calls fn with every shape of the body as it was last placed. points
+// is the transformed outline, or the center of a circle with the given radius.
+func (body *Body) EachShape(fn func(points []float32, radius float32)) {
+	for i := range body.parts {
+		fn(body.parts[i].points, body.parts[i].radius)
+	}
+}
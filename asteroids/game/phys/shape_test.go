@@ -0,0 +1,134 @@
+package phys
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func TestCircles(t *testing.T) {
+	world := NewWorld(800, 600, 60)
+	box := world.AddBody(nop{}, "box", 100, 100, 1, square)
+	mine := world.AddShapes(nop{}, "mine", 300, 100, 2, Circle(0, 0, 5))
+	if minX, _, maxX, _ := mine.GetBounds(); minX != 290 || maxX != 310 {
+		t.Errorf("a circle of 5 at scale 2 spans %v to %v", minX, maxX)
+	}
+
+	// the normal points from the box towards the circle's center
+	contacts := mine.Move(118, 100, 0, 2)
+	if len(contacts) != 1 || contacts[0].Body != box {
+		t.Fatalf("circle onto box touched %v", contacts)
+	}
+	if c := contacts[0]; !near(c.Depth, 2) || !near(c.NormalX, 1) || !near(c.NormalY, 0) || !near(c.X, 110) {
+		t.Errorf("circle onto box %+v", *c)
+	}
+	contacts = box.Move(99, 100, 0, 1)
+	if len(contacts) != 1 || !near(contacts[0].NormalX, -1) || !near(contacts[0].Depth, 1) {
+		t.Errorf("box onto circle %v", contacts)
+	}
+
+	// past the box's corner on the diagonal the bounds overlap but the circle
+	// doesn't
+	mine.Move(118, 118, 0, 2)
+	if contacts := box.Move(100, 100, 0, 1); len(contacts) != 0 {
+		t.Errorf("circle off the corner touched %+v", *contacts[0])
+	}
+
+	other := world.AddShapes(nop{}, "other", 400, 400, 1, Circle(0, 0, 10))
+	contacts = other.Move(130, 131, 0, 1)
+	depth := 20 - float32(math.Hypot(12, 13))
+	if len(contacts) != 1 || contacts[0].Body != mine || !near(contacts[0].Depth, depth) {
+		t.Errorf("circle onto circle %v", contacts)
+	}
+
+	if found := world.QueryPoint(112, 112); len(found) != 1 || found[0] != mine {
+		t.Errorf("QueryPoint found %v", found)
+	}
+	if found := world.QuerySegment(0, 118, 800, 118); len(found) != 1 || found[0] != mine {
+		t.Errorf("QuerySegment found %v", found)
+	}
+}
+
+func TestCompound(t *testing.T) {
+	world := NewWorld(800, 600, 60)
+	// an L of two boxes, turned a quarter clockwise at scale 2 so that local x, y
+	// ends up at 200-2y, 200+2x
+	ell := world.AddShapes(nop{}, "L", 200, 200, 2,
+		Polygon(0, 0, 10, 0, 10, 30, 0, 30),
+		Polygon(10, 20, 30, 20, 30, 30, 10, 30))
+	ell.Move(200, 200, math.Pi/2, 2)
+
+	shapes := 0
+	ell.EachShape(func(points []float32, radius float32) { shapes++ })
+	if shapes != 2 {
+		t.Errorf("EachShape gave %v shapes", shapes)
+	}
+	if minX, minY, maxX, maxY := ell.GetBounds(); !near(minX, 140) || !near(maxX, 200) || !near(minY, 200) || !near(maxY, 260) {
+		t.Errorf("bounds %v, %v to %v, %v", minX, minY, maxX, maxY)
+	}
+
+	// 20, 10 is in the notch of the L and 20, 25 is in its foot
+	if found := world.QueryPoint(180, 240); len(found) != 0 {
+		t.Errorf("the notch contains %v", found)
+	}
+	if found := world.QueryPoint(150, 240); len(found) != 1 {
+		t.Errorf("the foot contains %v", found)
+	}
+	probe := world.AddShapes(nop{}, "probe", 500, 500, 1, Circle(0, 0, 3))
+	if contacts := probe.Move(180, 240, 0, 1); len(contacts) != 0 {
+		t.Errorf("a circle in the notch touched %+v", *contacts[0])
+	}
+	if contacts := probe.Move(150, 240, 0, 1); len(contacts) != 1 {
+		t.Errorf("a circle in the foot touched %v", contacts)
+	}
+}
+
+func TestSweptCircles(t *testing.T) {
+	world := NewWorld(800, 600, 60)
+	wall := world.AddBody(nop{}, "wall", 400, 300, 1, []float32{-2, -50, 2, -50, 2, 50, -2, 50})
+	ball := world.AddShapes(nop{}, "ball", 100, 300, 1, Circle(0, 0, 4))
+	ball.SetSwept(true)
+	contacts := ball.Move(700, 300, 0, 1)
+	if len(contacts) != 1 || contacts[0].Body != wall {
+		t.Fatalf("a ball swept through a wall touched %v", contacts)
+	}
+	if c := contacts[0]; c.Time <= 0 || c.Time >= 1 || !near(c.NormalX, -1) {
+		t.Errorf("ball through wall %+v", *c)
+	}
+
+	mine := world.AddShapes(nop{}, "mine", 100, 100, 1, Circle(0, 0, 6))
+	bullet := world.AddBody(nop{}, "bullet", 50, 100, 1, []float32{-1, 0, 1, 0})
+	bullet.SetSwept(true)
+	contacts = bullet.Move(150, 100, 0, 1)
+	if len(contacts) != 1 || contacts[0].Body != mine || !near(contacts[0].X, 94) {
+		t.Errorf("a bullet swept through a mine touched %v", contacts)
+	}
+
+	small := world.AddShapes(nop{}, "small", 100, 200, 1, Circle(0, 0, 2))
+	target := world.AddShapes(nop{}, "target", 200, 200, 1, Circle(0, 0, 2))
+	small.SetSwept(true)
+	// the rims meet 96 of the 200 along
+	contacts = small.Move(300, 200, 0, 1)
+	if len(contacts) != 1 || contacts[0].Body != target || !near(contacts[0].Time, 0.48) {
+		t.Errorf("a circle swept through a circle touched %v", contacts)
+	}
+}
+
+func TestWrappingCircle(t *testing.T) {
+	world := NewWorld(800, 600, 60)
+	mine := world.AddShapes(nop{}, "mine", 795, 300, 1, Circle(0, 0, 10))
+	mine.SetWraps(true)
+	mine.Move(795, 300, 0, 1)
+	ghosts := 0
+	mine.EachGhost(func(dx, dy float32) { ghosts++ })
+	if ghosts != 1 {
+		t.Errorf("a circle over the right edge has %v ghosts", ghosts)
+	}
+	box := world.AddBody(nop{}, "box", 10, 300, 1, []float32{-5, -5, 5, -5, 5, 5, -5, 5})
+	if contacts := box.Move(8, 300, 0, 1); len(contacts) != 1 || contacts[0].Body != mine {
+		t.Errorf("a box by the left edge touched %v", contacts)
+	}
+}
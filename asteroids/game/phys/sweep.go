@@ -0,0 +1,152 @@
+package phys
+
+// SetSwept turns on continuous collision for the body. Small fast bodies like
+// bullets can move further than their own size in one step and skip straight
+// over something, so a swept body is tested along the whole path from its last
+// transform to its new one instead of only where it ends up.
+func (body *Body) SetSwept(swept bool) {
+	body.swept = swept
+	body.remember()
+}
+
+// remember keeps where each part is now as where the next sweep starts from.
+func (body *Body) remember() {
+	for i := range body.parts {
+		part := &body.parts[i]
+		part.prev = append(part.prev[:0], part.points...)
+	}
+}
+
+// sweep finds every body the swept body ran into between its previous and
+// current points. Each contact is where and when they first touched, with the
+// normal facing back against the motion.
+func (body *Body) sweep(touched uint64) {
+	minX, minY, maxX, maxY := body.minX, body.minY, body.maxX, body.maxY
+	for i := range body.parts {
+		part := &body.parts[i]
+		for j := 0; j+1 < len(part.prev); j += 2 {
+			minX, maxX = min(minX, part.prev[j]-part.radius), max(maxX, part.prev[j]+part.radius)
+			minY, maxY = min(minY, part.prev[j+1]-part.radius), max(maxY, part.prev[j+1]+part.radius)
+		}
+	}
+	world := body.world
+	for x := world.cellCoord(minX); x <= world.cellCoord(maxX); x++ {
+		for y := world.cellCoord(minY); y <= world.cellCoord(maxY); y++ {
+			cell, ok := world.cells[cellKey{x: x, y: y}]
+			if !ok {
+				continue
+			}
+			for _, other := range cell.bodies {
+				if other == body || other.touchedAt == touched || !body.collidesWith(other) || !other.overlapsBounds(minX, minY, maxX, maxY) {
+					continue
+				}
+				if body.timeOfImpact(other, &body.scratch) {
+					other.touchedAt = touched
+					body.keep(body.scratch)
+				}
+			}
+		}
+	}
+	var offsets [3][2]float32
+	for _, owner := range world.overhanging {
+		if owner == body || owner.touchedAt == touched || !body.collidesWith(owner) {
+			continue
+		}
+		for _, offset := range offsets[:owner.ghostOffsets(&offsets)] {
+			dx, dy := offset[0], offset[1]
+			if !owner.overlapsBounds(minX-dx, minY-dy, maxX-dx, maxY-dy) {
+				continue
+			}
+			if body.timeOfImpact(world.ghost(owner, dx, dy), &body.scratch) {
+				owner.touchedAt = touched
+				body.scratch.Body = owner
+				body.keep(body.scratch)
+				break
+			}
+		}
+	}
+}
+
+// timeOfImpact sweeps each of the body's parts against each of the other's,
+// keeping the earliest hit in contact with the normal facing back against the
+// motion.
+func (body *Body) timeOfImpact(other *Body, contact *Contact) bool {
+	found := false
+	var dx, dy float32
+	hit := func(t, x, y, nx, ny float32) {
+		if found && t >= contact.Time {
+			return
+		}
+		if nx*dx+ny*dy > 0 {
+			nx, ny = -nx, -ny
+		}
+		found = true
+		*contact = Contact{Body: other, Time: t, X: x, Y: y, NormalX: nx, NormalY: ny}
+	}
+
+	for i := range body.parts {
+		a := &body.parts[i]
+		if len(a.prev) != len(a.points) || len(a.points) == 0 {
+			continue
+		}
+		previous := part{points: a.prev, radius: a.radius}
+		px, py := previous.center()
+		cx, cy := a.center()
+		dx, dy = cx-px, cy-py
+		for j := range other.parts {
+			sweepPart(a, &previous, &other.parts[j], dx, dy, hit)
+		}
+	}
+	return found
+}
+
+// sweepPart casts each of a's vertices along its path from previous against b,
+// and each of b's vertices back along a's motion against previous. Circles are
+// cast by their center against the other part grown by their radius when it is
+// a circle too, and against its edges as they are when it is an outline, which
+// is enough to stop them passing through even if they are found touching a
+// little late.
+func sweepPart(a, previous, b *part, dx, dy float32, hit func(t, x, y, nx, ny float32)) {
+	edgeHit := func(t, x, y, ax, ay, bx, by float32) {
+		if nx, ny, ok := normalize(-(by - ay), bx-ax); ok {
+			hit(t, x, y, nx, ny)
+		}
+	}
+
+	for i := 0; i < len(a.points); i += 2 {
+		x0, y0, x1, y1 := previous.points[i], previous.points[i+1], a.points[i], a.points[i+1]
+		if b.round() {
+			ox, oy := b.points[0], b.points[1]
+			if t, ok := circleTime(x0, y0, x1, y1, ox, oy, b.radius+a.radius); ok {
+				x, y := x0+t*(x1-x0), y0+t*(y1-y0)
+				if nx, ny, ok := normalize(x-ox, y-oy); ok {
+					hit(t, ox+nx*b.radius, oy+ny*b.radius, nx, ny)
+				}
+			}
+			continue
+		}
+		b.eachEdge(func(ax, ay, bx, by float32) {
+			if t, ok := segmentTime(x0, y0, x1, y1, ax, ay, bx, by); ok {
+				edgeHit(t, x0+t*(x1-x0), y0+t*(y1-y0), ax, ay, bx, by)
+			}
+		})
+	}
+
+	for i := 0; i < len(b.points); i += 2 {
+		x, y := b.points[i], b.points[i+1]
+		if previous.round() {
+			ox, oy := previous.points[0], previous.points[1]
+			if t, ok := circleTime(x, y, x-dx, y-dy, ox, oy, previous.radius+b.radius); ok {
+				if nx, ny, ok := normalize(ox+t*dx-x, oy+t*dy-y); ok {
+					hit(t, x+nx*b.radius, y+ny*b.radius, nx, ny)
+				}
+			}
+			continue
+		}
+		previous.eachEdge(func(ax, ay, bx, by float32) {
+			if t, ok := segmentTime(x, y, x-dx, y-dy, ax, ay, bx, by); ok {
+				edgeHit(t, x, y, ax, ay, bx, by)
+			}
+		})
+	}
+}
@@ -1,102 +1,218 @@
 package phys
 
 import (
-	"fmt"
 	"math"
-
-	"github.com/tanema/amore/gfx"
+	"sort"
 )
 
+// Canvas is what the world and its bodies need to draw their debug overlay.
+type Canvas interface {
+	SetColor(r, g, b, a float32)
+	Line(x0, y0, x1, y1 float32)
+	Rect(x, y, width, height float32)
+}
+
+// World is a spatial hash of square cells. Cells are created the first time a
+// body's bounds touch them so bodies can leave the screen without falling off
+// a fixed grid, and dropped again once the last body leaves. A body only
+// re-hashes when its bounds cross a cell border.
 type World struct {
-	width       float32
-	height      float32
-	cell_width  float32
-	cell_height float32
-	numWidth    float32
-	numHeight   float32
-	grid        [][]*Cell
-}
-
-func NewWorld(width, height, cell_size float32) *World {
-	cell_width := nextEqualyDividable(width, cell_size)
-	cell_height := nextEqualyDividable(height, cell_size)
-	numWidth := width / cell_width
-	numHeight := height / cell_height
-
-	//initialize grid
-	grid := make([][]*Cell, int(numWidth))
-	for i := 0; i < int(numWidth); i++ {
-		grid[i] = make([]*Cell, int(numHeight))
-		for j := 0; j < int(numHeight); j++ {
-			grid[i][j] = &Cell{
-				x:      float32(i) * cell_width,
-				y:      float32(j) * cell_height,
-				width:  cell_width,
-				height: cell_height,
-			}
-		}
-	}
+	width    float32
+	height   float32
+	cellSize float32
+	cells    map[cellKey]*Cell
+	// dropped cells kept to be reused by the next new one
+	spare    []*Cell
+	count    int
+	nextID   uint64
+	frame    uint64
+	handlers []handlerEntry
+	touching map[pairKey]uint64
+	// wrapping bodies whose outline currently hangs over an edge
+	overhanging []*Body
+	// bumped to mark which bodies a move has already tested or touched
+	stamp      uint64
+	ghostProbe Body
+	flipped    Contact
+}
 
+func NewWorld(width, height, cellSize float32) *World {
 	return &World{
-		width:       width,
-		height:      height,
-		cell_width:  cell_width,
-		cell_height: cell_height,
-		numWidth:    numWidth,
-		numHeight:   numHeight,
-		grid:        grid,
+		width:    width,
+		height:   height,
+		cellSize: cellSize,
+		cells:    map[cellKey]*Cell{},
+		touching: map[pairKey]uint64{},
 	}
 }
 
-func nextEqualyDividable(unit, size float32) float32 {
-	for ; math.Mod(float64(unit), float64(size)) != 0; size++ {
-	}
-	return size
+func (world *World) AddBody(collidable Collidable, name string, x, y, scale float32, points []float32) *Body {
+	return world.AddShapes(collidable, name, x, y, scale, Polygon(points...))
 }
 
-func (world *World) AddBody(collidable Collidable, name string, x, y, scale float32, points []float32) *Body {
-	new_body := newBody(world, collidable, name, points)
-	new_body.Move(x, y, 0, scale)
+// AddShapes adds a body made of one or more shapes, which collide as one.
+func (world *World) AddShapes(collidable Collidable, name string, x, y, scale float32, shapes ...Shape) *Body {
+	world.nextID++
+	new_body := newBody(world, world.nextID, collidable, name, shapes)
+	new_body.place(x, y, 0, scale)
 	return new_body
 }
 
+func (world *World) cellCoord(v float32) int {
+	return int(math.Floor(float64(v / world.cellSize)))
+}
+
+func (world *World) cell(x, y int) *Cell {
+	key := cellKey{x: x, y: y}
+	cell, ok := world.cells[key]
+	if !ok {
+		if last := len(world.spare) - 1; last >= 0 {
+			cell = world.spare[last]
+			world.spare = world.spare[:last]
+		} else {
+			cell = &Cell{}
+		}
+		cell.key = key
+		cell.x, cell.y = float32(x)*world.cellSize, float32(y)*world.cellSize
+		cell.width, cell.height = world.cellSize, world.cellSize
+		world.cells[key] = cell
+	}
+	return cell
+}
+
+// drop forgets a cell the last body has left so that bodies wandering off or
+// wrapping around don't leave empty cells behind.
+func (world *World) drop(cell *Cell) {
+	delete(world.cells, cell.key)
+	world.spare = append(world.spare, cell)
+}
+
+// CellAt returns the cell that contains the point, or nil if no body is in it.
 func (world *World) CellAt(x, y float32) *Cell {
-	x = float32(math.Floor(float64(x / world.cell_width)))
-	y = float32(math.Floor(float64(y / world.cell_height)))
-	if x >= world.numWidth {
-		x -= world.numWidth
+	return world.cells[cellKey{x: world.cellCoord(x), y: world.cellCoord(y)}]
+}
+
+// update moves the body into the cells covered by its bounds. Nothing is done
+// if the bounds still cover the same cells as last time.
+func (world *World) update(body *Body) {
+	x0, y0 := world.cellCoord(body.minX), world.cellCoord(body.minY)
+	x1, y1 := world.cellCoord(body.maxX), world.cellCoord(body.maxY)
+	if len(body.cells) > 0 && body.cellRange == [4]int{x0, y0, x1, y1} {
+		return
 	}
-	if x < 0 {
-		x = world.numWidth - 1
+
+	if len(body.cells) == 0 {
+		world.count++
 	}
-	if y >= world.numHeight {
-		y = 0
+	body.leaveCells()
+	body.cellRange = [4]int{x0, y0, x1, y1}
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			world.cell(x, y).enter(body)
+		}
 	}
-	if y < 0 {
-		y = world.numHeight - 1
+}
+
+func (world *World) remove(body *Body) {
+	if len(body.cells) > 0 {
+		world.count--
 	}
-	return world.grid[int(x)][int(y)]
+	body.leaveCells()
+	world.updateOverhang(body)
 }
 
-func (world *World) DrawGrid() {
-	// This really sucks and isn't the best but it is for debugging so it shouldnt
-	// be used all the time
-	count := map[*Body]bool{}
-	for _, row := range world.grid {
-		for _, cell := range row {
+// query collects the bodies in the cells covered by the bounds that pass test,
+// each body at most once. Ghosts of wrapping bodies are tested too, in which
+// case test is handed the ghost but the body itself is returned.
+func (world *World) query(minX, minY, maxX, maxY float32, test func(*Body) bool) []*Body {
+	found := []*Body{}
+	seen := map[*Body]bool{}
+	hit := map[*Body]bool{}
+	for x := world.cellCoord(minX); x <= world.cellCoord(maxX); x++ {
+		for y := world.cellCoord(minY); y <= world.cellCoord(maxY); y++ {
+			cell, ok := world.cells[cellKey{x: x, y: y}]
+			if !ok {
+				continue
+			}
 			for _, body := range cell.bodies {
-				count[body] = true
+				if seen[body] {
+					continue
+				}
+				seen[body] = true
+				if body.overlapsBounds(minX, minY, maxX, maxY) && test(body) {
+					found = append(found, body)
+					hit[body] = true
+				}
 			}
 		}
 	}
+	world.eachGhost(minX, minY, maxX, maxY, func(owner, ghost *Body) {
+		if !hit[owner] && test(ghost) {
+			hit[owner] = true
+			found = append(found, owner)
+		}
+	})
+	return found
+}
+
+// QueryPoint returns every body whose outline contains the point.
+func (world *World) QueryPoint(x, y float32) []*Body {
+	return world.query(x, y, x, y, func(body *Body) bool {
+		return body.pointInside(x, y)
+	})
+}
+
+// QueryRect returns every body that overlaps the rectangle.
+func (world *World) QueryRect(x, y, width, height float32) []*Body {
+	probe := newProbe(Polygon(x, y, x+width, y, x+width, y+height, x, y+height))
+	return world.query(x, y, x+width, y+height, func(body *Body) bool {
+		var contact Contact
+		return collide(probe, body, &contact)
+	})
+}
+
+// QuerySegment returns every body the segment passes through, ordered by how far
+// along the segment they are hit.
+func (world *World) QuerySegment(x0, y0, x1, y1 float32) []*Body {
+	probe := newProbe(Polygon(x0, y0, x1, y1))
+	distances := map[*Body]float32{}
+	found := world.query(probe.minX, probe.minY, probe.maxX, probe.maxY, func(body *Body) bool {
+		var contact Contact
+		if collide(probe, body, &contact) {
+			dx, dy := contact.X-x0, contact.Y-y0
+			distances[body] = dx*dx + dy*dy
+			return true
+		}
+		return false
+	})
+	sort.SliceStable(found, func(i, j int) bool {
+		return distances[found[i]] < distances[found[j]]
+	})
+	return found
+}
+
+// QueryCircle returns every body that overlaps the circle, including wrapping
+// bodies hanging over the opposite edge.
+func (world *World) QueryCircle(x, y, radius float32) []*Body {
+	probe := newProbe(Circle(x, y, radius))
+	return world.query(x-radius, y-radius, x+radius, y+radius, func(body *Body) bool {
+		var contact Contact
+		return collide(probe, body, &contact)
+	})
+}
+
+// Count is the number of bodies currently in the world.
+func (world *World) Count() int {
+	return world.count
+}
 
-	gfx.Print(fmt.Sprintf("physical objects: %v", len(count)), 0, 30)
-	gfx.SetColor(100, 100, 100, 100)
-	for i := world.cell_width; i < world.width; i += world.cell_width {
-		gfx.Line(i, 0, i, world.height)
+func (world *World) DrawGrid(canvas Canvas) {
+	canvas.SetColor(100, 100, 100, 100)
+	for i := world.cellSize; i < world.width; i += world.cellSize {
+		canvas.Line(i, 0, i, world.height)
 	}
-	for i := world.cell_height; i < world.height; i += world.cell_height {
-		gfx.Line(0, i, world.width, i)
+	for i := world.cellSize; i < world.height; i += world.cellSize {
+		canvas.Line(0, i, world.width, i)
 	}
-	gfx.SetColor(255, 255, 255, 255)
+	canvas.SetColor(255, 255, 255, 255)
 }
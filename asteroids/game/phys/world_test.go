@@ -0,0 +1,96 @@
+package phys
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+type nop struct{}
+
+func (nop) Destroy(bool) {}
+
+var square = []float32{-10, -10, 10, -10, 10, 10, -10, 10}
+
+// crowd fills a world sized to keep about the same number of bodies per cell
+// whatever the count, so that the benchmarks show how the hash scales.
+func crowd(count int) (*World, []*Body) {
+	size := float32(20 * count)
+	world := NewWorld(size, size, 60)
+	rng := rand.New(rand.NewSource(1))
+	bodies := make([]*Body, count)
+	for i := range bodies {
+		bodies[i] = world.AddBody(nop{}, "body", rng.Float32()*size, rng.Float32()*size, 1, square)
+	}
+	return world, bodies
+}
+
+func TestQueries(t *testing.T) {
+	world := NewWorld(800, 600, 60)
+	a := world.AddBody(nop{}, "a", 100, 100, 1, square)
+	b := world.AddBody(nop{}, "b", 300, 100, 1, square)
+
+	if found := world.QueryPoint(300, 100); len(found) != 1 || found[0] != b {
+		t.Errorf("QueryPoint found %v", found)
+	}
+	if found := world.QueryRect(0, 0, 50, 50); len(found) != 0 {
+		t.Errorf("QueryRect found %v", found)
+	}
+	if found := world.QueryRect(80, 80, 240, 40); len(found) != 2 {
+		t.Errorf("QueryRect found %v", found)
+	}
+	if found := world.QuerySegment(800, 100, 0, 100); len(found) != 2 || found[0] != b || found[1] != a {
+		t.Errorf("QuerySegment found %v", found)
+	}
+	if found := world.QueryCircle(310, 125, 16); len(found) != 1 || found[0] != b {
+		t.Errorf("QueryCircle found %v", found)
+	}
+}
+
+func TestEmptyCellsAreDropped(t *testing.T) {
+	world := NewWorld(800, 600, 60)
+	body := world.AddBody(nop{}, "body", 30, 30, 1, square)
+	other := world.AddBody(nop{}, "other", 30, 30, 1, square)
+	for x := float32(30); x < 3000; x += 7 {
+		body.Move(x, x, 0, 1)
+	}
+	if len(world.cells) != len(body.cells)+len(other.cells) {
+		t.Errorf("%v cells for bodies in %v", len(world.cells), len(body.cells)+len(other.cells))
+	}
+	if world.CellAt(30, 30) == nil || world.CellAt(500, 30) != nil {
+		t.Error("CellAt should only find cells with bodies in them")
+	}
+	body.Remove()
+	other.Remove()
+	if len(world.cells) != 0 || world.Count() != 0 {
+		t.Errorf("%v cells and %v bodies left", len(world.cells), world.Count())
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	for _, count := range []int{1000, 10000} {
+		world, _ := crowd(count)
+		size := world.width
+		b.Run(fmt.Sprint("Point/", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				world.QueryPoint(float32(i%97)/97*size, float32(i%89)/89*size)
+			}
+		})
+		b.Run(fmt.Sprint("Rect/", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				world.QueryRect(float32(i%97)/97*size, float32(i%89)/89*size, 100, 100)
+			}
+		})
+		b.Run(fmt.Sprint("Segment/", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				x, y := float32(i%97)/97*size, float32(i%89)/89*size
+				world.QuerySegment(x, y, x+300, y+200)
+			}
+		})
+		b.Run(fmt.Sprint("Circle/", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				world.QueryCircle(float32(i%97)/97*size, float32(i%89)/89*size, 80)
+			}
+		})
+	}
+}
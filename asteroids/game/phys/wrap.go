@@ -0,0 +1,114 @@
+package phys
+
+// SetWraps marks the body as living on a torus the size of the world. While its
+// outline hangs over an edge the part that is off screen shows up on the
+// opposite side, and that ghost copy collides and is found by queries just like
+// the body itself.
+func (body *Body) SetWraps(wraps bool) {
+	body.wraps = wraps
+	body.world.updateOverhang(body)
+}
+
+// EachGhost calls fn with the offset of every copy of a wrapping body that shows
+// up across an edge of the world, at most three when it hangs over a corner.
+func (body *Body) EachGhost(fn func(dx, dy float32)) {
+	var offsets [3][2]float32
+	for _, offset := range offsets[:body.ghostOffsets(&offsets)] {
+		fn(offset[0], offset[1])
+	}
+}
+
+// ghostOffsets fills offsets with the ones EachGhost would call back with and
+// returns how many there are, for the hot paths that can't afford a closure.
+func (body *Body) ghostOffsets(offsets *[3][2]float32) int {
+	if !body.wraps {
+		return 0
+	}
+	width, height := body.world.width, body.world.height
+	var dx, dy float32
+	if body.minX < 0 {
+		dx = width
+	} else if body.maxX > width {
+		dx = -width
+	}
+	if body.minY < 0 {
+		dy = height
+	} else if body.maxY > height {
+		dy = -height
+	}
+	count := 0
+	if dy != 0 {
+		offsets[count] = [2]float32{0, dy}
+		count++
+	}
+	if dx != 0 {
+		offsets[count] = [2]float32{dx, 0}
+		count++
+	}
+	if dx != 0 && dy != 0 {
+		offsets[count] = [2]float32{dx, dy}
+		count++
+	}
+	return count
+}
+
+func (body *Body) overhangs() bool {
+	return body.wraps && (body.minX < 0 || body.minY < 0 || body.maxX > body.world.width || body.maxY > body.world.height)
+}
+
+// ghost returns the world's probe with the body's shapes moved by the offset,
+// used to run the narrow phase against a ghost. There is only one probe so it is
+// only good until the next call.
+func (world *World) ghost(body *Body, dx, dy float32) *Body {
+	probe := &world.ghostProbe
+	if cap(probe.parts) < len(body.parts) {
+		probe.parts = append(probe.parts[:cap(probe.parts)], make([]part, len(body.parts)-cap(probe.parts))...)
+	}
+	// parts past the length keep their points so growing back doesn't allocate
+	probe.parts = probe.parts[:len(body.parts)]
+	for i := range body.parts {
+		src, dst := &body.parts[i], &probe.parts[i]
+		dst.points = dst.points[:0]
+		for j := 0; j < len(src.points); j += 2 {
+			dst.points = append(dst.points, src.points[j]+dx, src.points[j+1]+dy)
+		}
+		dst.radius = src.radius
+		dst.updateBounds()
+	}
+	probe.updateBounds()
+	return probe
+}
+
+// updateOverhang keeps the list of wrapping bodies with ghosts up to date. It is
+// a slice rather than a map so contacts with ghosts are always found in the same
+// order, which replays depend on.
+func (world *World) updateOverhang(body *Body) {
+	listed := body.overhangIndex > 0
+	if wanted := len(body.cells) > 0 && body.overhangs(); wanted == listed {
+		return
+	} else if wanted {
+		world.overhanging = append(world.overhanging, body)
+		body.overhangIndex = len(world.overhanging)
+		return
+	}
+	last := len(world.overhanging) - 1
+	moved := world.overhanging[last]
+	world.overhanging[body.overhangIndex-1] = moved
+	moved.overhangIndex = body.overhangIndex
+	world.overhanging = world.overhanging[:last]
+	body.overhangIndex = 0
+}
+
+// eachGhost calls fn with a probe for every ghost of the bodies hanging over an
+// edge whose bounds overlap the given ones.
+func (world *World) eachGhost(minX, minY, maxX, maxY float32, fn func(owner, ghost *Body)) {
+	var offsets [3][2]float32
+	for _, owner := range world.overhanging {
+		for _, offset := range offsets[:owner.ghostOffsets(&offsets)] {
+			dx, dy := offset[0], offset[1]
+			if owner.overlapsBounds(minX-dx, minY-dy, maxX-dx, maxY-dy) {
+				fn(owner, world.ghost(owner, dx, dy))
+			}
+		}
+	}
+}
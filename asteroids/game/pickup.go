@@ -0,0 +1,76 @@
+package game
+
+const (
+	pickupChance    = 0.1
+	pickupLife      = 12
+	pickupSpeedLoss = 0.5
+	pickupSpin      = 1
+	pickupBlinkTime = 3
+)
+
+var pickupPoints = []float32{
+	0, -8,
+	8, 0,
+	0, 8,
+	-8, 0,
+	0, -8,
+}
+
+// Pickup is a power up dropped by a destroyed asteroid. It drifts around until
+// the ship flies into it or it runs out of time.
+type Pickup struct {
+	*Sprite
+	power powerUp
+	life  float32
+}
+
+// dropPickup sometimes leaves a random power up behind where an asteroid was
+// destroyed, drifting along with what is left of its velocity.
+func (game *Game) dropPickup(x, y, vx, vy float32) {
+	if game.rng.Float32() >= pickupChance {
+		return
+	}
+	pickup := &Pickup{power: powerUp(game.rng.Intn(len(powerUps)))}
+	pickup.Sprite = NewSprite(game, pickup, "pickup", x, y, 1, pickupPoints, true)
+	pickup.body.SetFilter(categoryPickup, maskPickup)
+	pickup.vx, pickup.vy = vx*pickupSpeedLoss, vy*pickupSpeedLoss
+	pickup.vrot = pickupSpin
+	game.entities.Spawn(pickup)
+}
+
+func (pickup *Pickup) Kind() Kind {
+	return KindPickup
+}
+
+func (pickup *Pickup) Update(dt float32) {
+	pickup.UpdateMovement(dt)
+	pickup.life += dt
+	if pickup.life >= pickupLife {
+		pickup.Destroy(true)
+	}
+}
+
+func (pickup *Pickup) Draw() {
+	// blink when about to disappear
+	if left := pickupLife - pickup.life; left < pickupBlinkTime && int(left*pickup.game.tuning.PlayerBlinkRate)%2 == 0 {
+		return
+	}
+	pickup.Sprite.Draw()
+	pickup.game.renderer.Print(powerUps[pickup.power].letter, pickup.x-3, pickup.y-6, 1, 1)
+}
+
+// collected hands the power up to the ship.
+func (pickup *Pickup) collected(player *Player) {
+	if !pickup.game.entities.Alive(pickup) {
+		return
+	}
+	pickup.Destroy(false)
+	player.collect(pickup.power)
+}
+
+func (pickup *Pickup) Destroy(force bool) {
+	if !pickup.game.entities.Despawn(pickup) {
+		return
+	}
+	pickup.Sprite.Destroy()
+}
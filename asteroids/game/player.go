@@ -1,103 +1,195 @@
 package game
 
 import (
-	"github.com/tanema/amore/gfx"
-	"github.com/tanema/amore/keyboard"
-)
+	"fmt"
+	"math"
 
-const (
-	playerAcc           = 200
-	playerMaxSpeed      = 400
-	playerRotationSpeed = 6
-	playerFireRate      = 0.40
-	playerJetSize       = 25
-	playerJetWidth      = 0.15
+	"github.com/tanema/amore-examples/asteroids/game/particles"
 )
 
+var exhaustParticles = particles.Config{
+	Rate:     60,
+	LifeMin:  0.15,
+	LifeMax:  0.35,
+	SpeedMin: 80,
+	SpeedMax: 140,
+	Spread:   0.25,
+	Drag:     1,
+	Inherit:  1,
+	Colors: particles.Ramp{
+		{R: 255, G: 255, B: 200, A: 255},
+		{R: 255, G: 140, B: 40, A: 180},
+		{R: 160, G: 40, B: 20, A: 0},
+	},
+}
+
 type Player struct {
 	*Sprite
+	seat           *seat
+	class          ShipClass
+	hyperspace     float32
 	lastFire       float32
 	isAccelerating bool
+	exhaust        *particles.Emitter
+	invulnerable   float32
+	shield         float32
+	shielded       bool
+	modifiers      []*modifier
 }
 
-func newPlayer() *Player {
-	new_player := &Player{}
-	new_player.Sprite = NewSprite(new_player, "ship", screenWidth/2, screenHeight/2, 1,
-		[]float32{
-			-5, 4,
-			0, -12,
-			5, 4,
-			-5, 4,
-		}, true)
+func newPlayer(game *Game, seat *seat) *Player {
+	new_player := &Player{
+		seat:         seat,
+		class:        game.ships[seat.ship],
+		invulnerable: game.tuning.PlayerInvulnerable,
+		shield:       game.tuning.PlayerShieldTime,
+		exhaust:      particles.NewEmitter(exhaustParticles, game.fx),
+	}
+	x, y := game.spawnPoint(seat)
+	new_player.Sprite = NewSprite(game, new_player, "ship", x, y, 1, new_player.class.Outline, true)
+	if game.versus {
+		new_player.body.SetFilter(categoryShip, maskShip|categoryBullet)
+	} else {
+		new_player.body.SetFilter(categoryShip, maskShip)
+	}
+	new_player.restitution = shieldRestitution
 	return new_player
 }
 
+func (player *Player) Kind() Kind {
+	return KindPlayer
+}
+
 func (player *Player) Update(dt float32) {
 	player.isAccelerating = false
+	input := player.seat.input
 
-	if keyboard.IsDown(keyboard.KeyLeft) {
-		player.vrot = -playerRotationSpeed
-	} else if keyboard.IsDown(keyboard.KeyRight) {
-		player.vrot = playerRotationSpeed
+	if input.has(InputLeft) {
+		player.vrot = -player.class.TurnRate
+	} else if input.has(InputRight) {
+		player.vrot = player.class.TurnRate
 	} else {
 		player.vrot = 0
 	}
 
-	if keyboard.IsDown(keyboard.KeyUp) {
+	// thrust adds to whatever the wells are pulling the ship with
+	if input.has(InputThrust) {
 		player.isAccelerating = true
-		player.ay = -(playerAcc * cos(player.rot))
-		player.ax = playerAcc * sin(player.rot)
-	} else {
-		player.ax = 0
-		player.ay = 0
+		player.ay -= player.class.Thrust * cos(player.rot)
+		player.ax += player.class.Thrust * sin(player.rot)
 	}
 
+	player.updateModifiers(dt)
+	weapon := player.loadout()
+
+	// the shield bumps asteroids away instead of dying, it drains while held and
+	// slowly charges back up while it isn't. A shield power up keeps it up for free.
+	held := input.has(InputShield) && player.shield > 0
+	player.shielded = weapon.shield || held
+	if held && !weapon.shield {
+		player.shield = max(0, player.shield-dt)
+	} else if !held {
+		player.shield = min(player.game.tuning.PlayerShieldTime, player.shield+dt*player.game.tuning.PlayerShieldCharge)
+	}
+
+	player.invulnerable = max(0, player.invulnerable-dt)
 	player.lastFire += dt
-	if keyboard.IsDown(keyboard.KeySpace) && player.lastFire > playerFireRate {
-		addObject(newBullet(player.x, player.y, player.rot))
-		lazer.Play()
+	if input.has(InputFire) && player.lastFire > weapon.fireRate {
+		weapon.fire(player)
 		player.lastFire = 0
 	}
 
-	if collisions := player.UpdateMovement(dt); len(collisions) > 0 {
-		for _, c := range collisions {
-			if c.Name == "asteroid" {
-				player.Destroy(false)
-			}
-		}
+	player.hyperspace = max(0, player.hyperspace-dt)
+	if player.seat.pressed(InputHyperspace) && player.hyperspace == 0 {
+		player.jump()
+		return
 	}
 
+	player.UpdateMovement(dt)
+	player.updateExhaust(dt)
+
+	drag := max(0, 1-player.class.Drag*dt)
+	player.vx, player.vy = player.vx*drag, player.vy*drag
+
 	// limit the ship's speed
-	if sqrt(player.vx*player.vx+player.vy*player.vy) > playerMaxSpeed {
+	if sqrt(player.vx*player.vx+player.vy*player.vy) > player.class.MaxSpeed {
 		player.vx *= 0.95
 		player.vy *= 0.95
 	}
 }
 
-func (player *Player) Draw() {
-	player.Sprite.Draw()
+// updateExhaust blows particles out from between the rear corners of the ship
+// while it is thrusting.
+func (player *Player) updateExhaust(dt float32) {
+	points := player.body.GetPoints()
+	player.exhaust.X, player.exhaust.Y = (points[0]+points[4])/2, (points[1]+points[5])/2
+	player.exhaust.VX, player.exhaust.VY = player.vx, player.vy
+	player.exhaust.Direction = player.rot + math.Pi
+	player.exhaust.Emitting = player.isAccelerating
+	player.exhaust.Update(dt)
+}
 
-	if player.isAccelerating {
-		points := player.Sprite.body.GetPoints()
-		gfx.PolyLine([]float32{
-			points[0] + ((points[4] - points[0]) * playerJetWidth),
-			points[1] + ((points[5] - points[1]) * playerJetWidth),
+// jump takes the ship through hyperspace to a random spot on the screen, where
+// it comes out at a standstill unless the jump fails and destroys it.
+func (player *Player) jump() {
+	game := player.game
+	player.hyperspace = player.class.Hyperspace.Cooldown
+	if game.rng.Float32() < player.class.Hyperspace.FailChance {
+		player.Destroy(false)
+		return
+	}
+	player.x, player.y = game.randMax(game.screenWidth), game.randMax(game.screenHeight)
+	player.vx, player.vy = 0, 0
+	player.body.Move(player.x, player.y, player.rot, player.scale)
+}
 
-			points[2] + (-sin(player.rot) * playerJetSize),
-			points[3] + (cos(player.rot) * playerJetSize),
+func (player *Player) drawHyperspace(x, y float32) {
+	if player.hyperspace == 0 {
+		player.game.renderer.Print("Hyperspace: ready", x, y, 1, 1)
+	} else {
+		player.game.renderer.Print(fmt.Sprintf("Hyperspace: %.1f", player.hyperspace), x, y, 1, 1)
+	}
+}
 
-			points[4] + ((points[0] - points[4]) * playerJetWidth),
-			points[5] + ((points[1] - points[5]) * playerJetWidth),
-		})
+// hit kills the ship unless it has just respawned.
+func (player *Player) hit() {
+	if player.invulnerable == 0 && player.seat.player == player {
+		player.Destroy(false)
 	}
 }
 
+func (player *Player) Draw() {
+	// blink while invulnerable
+	if player.invulnerable > 0 && int(player.invulnerable*player.game.tuning.PlayerBlinkRate)%2 == 0 {
+		return
+	}
+
+	color := seatColors[player.seat.index]
+	player.game.renderer.SetColor(color[0], color[1], color[2], 255)
+	player.Sprite.Draw()
+
+	if player.shielded {
+		shield := []float32{}
+		radius := player.game.tuning.PlayerShieldRadius
+		for i := 0; i <= 8; i++ {
+			angle := float32(i) * math.Pi / 4
+			shield = append(shield, player.x+sin(angle)*radius, player.y+cos(angle)*radius)
+		}
+		player.game.renderer.PolyLine(shield)
+	}
+	player.game.renderer.SetColor(255, 255, 255, 255)
+
+	player.game.drawParticles(player.exhaust)
+}
+
 func (player *Player) Destroy(force bool) {
-	removeObject(player)
+	if !player.game.entities.Despawn(player) {
+		return
+	}
 	player.Sprite.Destroy()
 	if !force {
-		bomb.Play()
-		gameOver = true
-		newExplosion(player.Sprite)
+		player.game.emit(event{kind: eventShipDestroyed})
+		player.game.playerDied(player.seat)
+		newExplosion(player.game, player.GetPoints())
 	}
 }
@@ -0,0 +1,157 @@
+package game
+
+import (
+	"fmt"
+)
+
+const (
+	rapidFireSpeedUp = 3
+	spreadAngle      = 0.15
+	pierceHits       = 3
+)
+
+type powerUp int
+
+const (
+	powerShield powerUp = iota
+	powerSpread
+	powerRapid
+	powerPiercing
+	powerBomb
+)
+
+// stacking decides what collecting a power up does while it is already active.
+type stacking int
+
+const (
+	// stackRefresh restarts the timer
+	stackRefresh stacking = iota
+	// stackExtend adds the duration to what is left, up to the limit
+	stackExtend
+	// stackLevel restarts the timer and makes the effect stronger, up to the limit
+	stackLevel
+)
+
+// powerUps describes every power up. The ones without a duration take effect
+// right away and never go on the modifier stack.
+var powerUps = []struct {
+	name     string
+	letter   string
+	duration float32
+	stacking stacking
+	limit    float32
+	apply    func(weapon *loadout, level int)
+	trigger  func(player *Player)
+}{
+	powerShield: {name: "Shield", letter: "S", duration: 8, stacking: stackExtend, limit: 20, apply: func(weapon *loadout, level int) {
+		weapon.shield = true
+	}},
+	powerSpread: {name: "Spread", letter: "W", duration: 10, stacking: stackLevel, limit: 2, apply: func(weapon *loadout, level int) {
+		weapon.shots += 2 * level
+	}},
+	powerRapid: {name: "Rapid", letter: "R", duration: 10, stacking: stackRefresh, apply: func(weapon *loadout, level int) {
+		weapon.fireRate /= rapidFireSpeedUp
+	}},
+	powerPiercing: {name: "Pierce", letter: "P", duration: 10, stacking: stackRefresh, apply: func(weapon *loadout, level int) {
+		weapon.pierce = pierceHits
+	}},
+	powerBomb: {name: "Bomb", letter: "B", trigger: smartBomb},
+}
+
+// loadout is what the ship can do this step once every active power up has had
+// its say.
+type loadout struct {
+	fireRate float32
+	shots    int
+	pierce   int
+	shield   bool
+}
+
+// modifier is a power up on the ship's stack and how long it has left.
+type modifier struct {
+	power powerUp
+	time  float32
+	level int
+}
+
+// loadout applies the modifier stack, bottom first, to the ship's own weapon.
+func (player *Player) loadout() loadout {
+	weapon := loadout{fireRate: player.class.Weapon.FireRate, shots: player.class.Weapon.Shots}
+	for _, mod := range player.modifiers {
+		powerUps[mod.power].apply(&weapon, mod.level)
+	}
+	return weapon
+}
+
+// collect gives the ship a power up, following its stacking rules if it already
+// has it.
+func (player *Player) collect(power powerUp) {
+	class := powerUps[power]
+	if class.trigger != nil {
+		class.trigger(player)
+		return
+	}
+	for _, mod := range player.modifiers {
+		if mod.power != power {
+			continue
+		}
+		switch class.stacking {
+		case stackRefresh:
+			mod.time = class.duration
+		case stackExtend:
+			mod.time = min(class.limit, mod.time+class.duration)
+		case stackLevel:
+			mod.time = class.duration
+			mod.level = int(min(class.limit, float32(mod.level+1)))
+		}
+		return
+	}
+	player.modifiers = append(player.modifiers, &modifier{power: power, time: class.duration, level: 1})
+}
+
+// updateModifiers counts down the stack and drops whatever ran out.
+func (player *Player) updateModifiers(dt float32) {
+	active := player.modifiers[:0]
+	for _, mod := range player.modifiers {
+		if mod.time -= dt; mod.time > 0 {
+			active = append(active, mod)
+		}
+	}
+	player.modifiers = active
+}
+
+// fire spawns the loadout's bullets fanned out around the ship's nose.
+func (weapon loadout) fire(player *Player) {
+	game := player.game
+	for i := 0; i < weapon.shots; i++ {
+		offset := (float32(i) - float32(weapon.shots-1)/2) * spreadAngle
+		bullet := newBullet(game, player.x, player.y, player.rot+offset, player.seat)
+		bullet.pierce = weapon.pierce
+		game.entities.Spawn(bullet)
+	}
+	game.emit(event{kind: eventShot})
+}
+
+// smartBomb destroys everything that is on screen and scores it for the ship
+// that collected it.
+func smartBomb(player *Player) {
+	game := player.game
+	game.entities.EachKind(KindAsteroid, func(id EntityID, object GameObject) {
+		asteroid := object.(*Asteroid)
+		game.addScore(player.seat, asteroidClasses[asteroid.size].points)
+		asteroid.Destroy(false)
+	})
+	if game.saucer != nil {
+		game.saucer.hit(player.seat)
+	}
+}
+
+func (player *Player) drawModifiers(x, y float32) {
+	for i, mod := range player.modifiers {
+		label := powerUps[mod.power].name
+		if mod.level > 1 {
+			label = fmt.Sprintf("%v x%v", label, mod.level)
+		}
+		player.game.renderer.Print(fmt.Sprintf("%v: %.1f", label, mod.time), x, y+float32(i)*15, 1, 1)
+	}
+}
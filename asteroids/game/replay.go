@@ -0,0 +1,82 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+const replayVersion = 2
+
+// Replay is everything needed to play a round back exactly: the seed the round
+// was started with, the mode and ship showing on the start screen, the ship
+// classes and tuning it was played with, and the input of every simulation step.
+// Keeping the data means editing the data files doesn't change old replays.
+type Replay struct {
+	Version int         `json:"version"`
+	Seed    int64       `json:"seed"`
+	Mode    Mode        `json:"mode"`
+	Ship    int         `json:"ship"`
+	Ships   []ShipClass `json:"ships"`
+	Tuning  Tuning      `json:"tuning"`
+	Frames  []byte      `json:"frames"`
+}
+
+func newReplay(seed int64, mode Mode, ship int, ships []ShipClass, tuning Tuning) *Replay {
+	return &Replay{
+		Version: replayVersion,
+		Seed:    seed,
+		Mode:    mode,
+		Ship:    ship,
+		Ships:   ships,
+		Tuning:  tuning,
+		Frames:  []byte{},
+	}
+}
+
+// LoadReplay reads a replay saved with Save.
+func LoadReplay(path string) (*Replay, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	replay := &Replay{}
+	if err := json.Unmarshal(data, replay); err != nil {
+		return nil, err
+	}
+	if replay.Version != replayVersion {
+		return nil, fmt.Errorf("replay %v has version %v, expected %v", path, replay.Version, replayVersion)
+	}
+	if len(replay.Ships) == 0 {
+		return nil, fmt.Errorf("replay %v: no ships", path)
+	}
+	for _, ship := range replay.Ships {
+		if err := ship.validate(); err != nil {
+			return nil, fmt.Errorf("replay %v: ship %q: %v", path, ship.Name, err)
+		}
+	}
+	if err := replay.Tuning.validate(); err != nil {
+		return nil, fmt.Errorf("replay %v: %v", path, err)
+	}
+	return replay, nil
+}
+
+// Save writes the replay to path.
+func (replay *Replay) Save(path string) error {
+	data, err := json.Marshal(replay)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (replay *Replay) record(input Input) {
+	replay.Frames = append(replay.Frames, byte(input))
+}
+
+func (replay *Replay) frame(i int) (Input, bool) {
+	if i >= len(replay.Frames) {
+		return 0, false
+	}
+	return Input(replay.Frames[i]), true
+}
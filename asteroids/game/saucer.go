@@ -0,0 +1,175 @@
+package game
+
+import (
+	"math"
+)
+
+const (
+	saucerInterval     = 15
+	saucerTurnTime     = 2
+	saucerLargeSpeed   = 80
+	saucerSmallSpeed   = 120
+	saucerLargeFire    = 1.5
+	saucerSmallFire    = 1
+	saucerSmallSpread  = 0.15
+	saucerLargePoints  = 200
+	saucerSmallPoints  = 1000
+	saucerSmallChance  = 0.2
+	saucerSmallPerWave = 0.1
+)
+
+var saucerPoints = []float32{
+	-10, 0,
+	-4, -3,
+	-2, -6,
+	2, -6,
+	4, -3,
+	10, 0,
+	4, 4,
+	-4, 4,
+	-10, 0,
+}
+
+// Saucer is the enemy ship that crosses the screen shooting at the player. The
+// large saucer shoots in random directions while the small one leads its shots.
+type Saucer struct {
+	*Sprite
+	small     bool
+	travelled float32
+	lastFire  float32
+	turnTimer float32
+	speed     float32
+	fireRate  float32
+	points    int
+}
+
+func newSaucer(game *Game, small bool) *Saucer {
+	saucer := &Saucer{
+		small:    small,
+		speed:    saucerLargeSpeed,
+		fireRate: saucerLargeFire,
+		points:   saucerLargePoints,
+	}
+	scale := float32(2)
+	if small {
+		scale = 1
+		saucer.speed = saucerSmallSpeed
+		saucer.fireRate = saucerSmallFire
+		saucer.points = saucerSmallPoints
+	}
+
+	x, direction := float32(0), float32(1)
+	if game.rng.Intn(2) == 0 {
+		x, direction = game.screenWidth, -1
+	}
+	saucer.Sprite = NewSprite(game, saucer, "saucer", x, game.randMax(game.screenHeight), scale, saucerPoints, true)
+	saucer.body.SetFilter(categorySaucer, maskSaucer)
+	saucer.vx = saucer.speed * direction
+	return saucer
+}
+
+func (saucer *Saucer) Kind() Kind {
+	return KindSaucer
+}
+
+func (saucer *Saucer) Update(dt float32) {
+	saucer.turnTimer += dt
+	if saucer.turnTimer >= saucerTurnTime {
+		saucer.turnTimer = 0
+		saucer.vy = float32(saucer.game.rng.Intn(3)-1) * saucer.speed / 2
+	}
+
+	saucer.lastFire += dt
+	if saucer.lastFire >= saucer.fireRate && saucer.game.flying() {
+		saucer.lastFire = 0
+		saucer.game.entities.Spawn(newBullet(saucer.game, saucer.x, saucer.y, saucer.aim(), nil))
+		saucer.game.emit(event{kind: eventSaucerShot})
+	}
+
+	saucer.UpdateMovement(dt)
+
+	saucer.travelled += abs(saucer.vx * dt)
+	if saucer.travelled >= saucer.game.screenWidth {
+		saucer.Destroy(true)
+	}
+}
+
+// aim picks the direction of the next shot. The large saucer fires at random,
+// the small one leads the closest ship and only misses by a little.
+func (saucer *Saucer) aim() float32 {
+	if !saucer.small {
+		return saucer.game.randMax(2 * math.Pi)
+	}
+	player := saucer.game.nearestPlayer(saucer.x, saucer.y)
+	rot := intercept(saucer.x, saucer.y, player.x, player.y, player.vx, player.vy, saucer.game.tuning.BulletSpeed)
+	return rot + saucer.game.randLimits(saucerSmallSpread)
+}
+
+// intercept returns the rotation to fire a bullet at speed from x, y so that it
+// meets a target moving with a constant velocity. If the bullet can't catch the
+// target it fires straight at where the target is now.
+func intercept(x, y, tx, ty, tvx, tvy, speed float32) float32 {
+	dx, dy := tx-x, ty-y
+	a := tvx*tvx + tvy*tvy - speed*speed
+	b := 2 * (dx*tvx + dy*tvy)
+	c := dx*dx + dy*dy
+	t := float32(-1)
+	if a == 0 {
+		if b != 0 {
+			t = -c / b
+		}
+	} else if disc := b*b - 4*a*c; disc >= 0 {
+		root := sqrt(disc)
+		t0, t1 := (-b-root)/(2*a), (-b+root)/(2*a)
+		t = max(t0, t1)
+		if min(t0, t1) > 0 {
+			t = min(t0, t1)
+		}
+	}
+	if t > 0 {
+		dx, dy = dx+tvx*t, dy+tvy*t
+	}
+	return atan2(dx, -dy)
+}
+
+// hit destroys the saucer, scoring it for the seat that shot it if any.
+func (saucer *Saucer) hit(scorer *seat) {
+	if !saucer.game.entities.Alive(saucer) {
+		return
+	}
+	if scorer != nil {
+		saucer.game.addScore(scorer, saucer.points)
+	}
+	saucer.Destroy(false)
+}
+
+func (saucer *Saucer) Destroy(force bool) {
+	if !saucer.game.entities.Despawn(saucer) {
+		return
+	}
+	saucer.Sprite.Destroy()
+	if saucer.game.saucer == saucer {
+		saucer.game.saucer = nil
+	}
+	if !force {
+		saucer.game.emit(event{kind: eventSaucerDestroyed})
+		newExplosion(saucer.game, saucer.GetPoints())
+	}
+}
+
+// updateSaucer sends a saucer across the screen every saucerInterval seconds
+// while a ship is flying. Small saucers show up more the later the wave. The
+// gravity mode has no saucers.
+func (game *Game) updateSaucer(dt float32) {
+	if game.mode != ModeClassic || game.saucer != nil || !game.flying() || game.entities.Count(KindAsteroid) == 0 {
+		return
+	}
+	game.saucerTimer += dt
+	if game.saucerTimer < saucerInterval {
+		return
+	}
+	game.saucerTimer = 0
+	small := game.rng.Float32() < saucerSmallChance+float32(game.wave-1)*saucerSmallPerWave
+	game.saucer = newSaucer(game, small)
+	game.entities.Spawn(game.saucer)
+}
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/tanema/amore-examples/highscore"
+)
+
+// SetHighScores gives the game a table to record the best scores of a mode in.
+// Without one the game doesn't keep any high scores for that mode.
+func (game *Game) SetHighScores(mode Mode, table *highscore.Table) {
+	game.scores[mode] = table
+}
+
+// Initials returns the initials being entered for a new high score, or nil if
+// the player isn't entering any.
+func (game *Game) Initials() *highscore.Initials {
+	return game.initials
+}
+
+// SubmitInitials adds the finished round's score to the high score table under
+// the initials that were entered.
+func (game *Game) SubmitInitials() {
+	if game.initials == nil {
+		return
+	}
+	table := game.scores[game.mode]
+	table.Add(game.initials.Value(), game.seats[0].score)
+	if err := table.Save(); err != nil {
+		fmt.Println("could not save high scores:", err)
+	}
+	game.initials = nil
+}
+
+// checkHighScore starts initials entry if the round that just ended made it into
+// the table. Replays and network rounds never count.
+func (game *Game) checkHighScore() {
+	table, score := game.scores[game.mode], game.seats[0].score
+	if table != nil && len(game.seats) == 1 && game.playback == nil && !game.attract && score > 0 && table.Qualifies(score) {
+		game.initials = &highscore.Initials{}
+	}
+}
+
+func (game *Game) drawHighScores(x, y float32) {
+	if game.initials != nil {
+		game.renderer.Print("New high score! Enter your initials:", x, y, 1, 1)
+		game.renderer.Print(game.initials.String(), x, y+20, 2, 2)
+		return
+	}
+	table := game.scores[game.mode]
+	if table == nil {
+		return
+	}
+	game.renderer.Print(fmt.Sprintf("Best %v scores", game.mode), x, y, 1, 1)
+	for i, entry := range table.Entries {
+		game.renderer.Print(fmt.Sprintf("%2v. %-3v %8v", i+1, entry.Initials, entry.Score), x, y+float32(i+1)*15, 1, 1)
+	}
+}
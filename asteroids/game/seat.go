@@ -0,0 +1,70 @@
+package game
+
+const (
+	maxSeats     = 2
+	seatSpacing  = 60
+	versusPoints = 1000
+)
+
+// seat is someone playing the round: the controls they are holding this step,
+// their ship while it is flying, and their lives and score. A single player
+// round has one seat, a networked round one for each end.
+type seat struct {
+	index        int
+	input        Input
+	lastInput    Input
+	ship         int
+	player       *Player
+	score        int
+	lives        int
+	deaths       int
+	respawnTimer float32
+}
+
+// seatColors tells the ships apart in a two player round.
+var seatColors = [maxSeats][3]float32{
+	{255, 255, 255},
+	{120, 220, 255},
+}
+
+// pressed is true only on the step the control went down.
+func (seat *seat) pressed(flag Input) bool {
+	return seat.input.has(flag) && !seat.lastInput.has(flag)
+}
+
+// spawnPoint is where the seat's ship appears, side by side in the middle of
+// the screen when there are two.
+func (game *Game) spawnPoint(seat *seat) (float32, float32) {
+	x := game.screenWidth / 2
+	if len(game.seats) > 1 {
+		x += (float32(seat.index) - float32(len(game.seats)-1)/2) * seatSpacing
+	}
+	return x, game.screenHeight / 2
+}
+
+// nearestPlayer is the ship closest to x, y, going the short way around the
+// wrapped screen, or nil if none are flying.
+func (game *Game) nearestPlayer(x, y float32) *Player {
+	var nearest *Player
+	var best float32
+	for _, seat := range game.seats {
+		if seat.player == nil {
+			continue
+		}
+		dx, dy := game.offset(x, y, seat.player.x, seat.player.y)
+		if distance := dx*dx + dy*dy; nearest == nil || distance < best {
+			nearest, best = seat.player, distance
+		}
+	}
+	return nearest
+}
+
+// flying reports if any seat has a ship on screen.
+func (game *Game) flying() bool {
+	for _, seat := range game.seats {
+		if seat.player != nil {
+			return true
+		}
+	}
+	return false
+}
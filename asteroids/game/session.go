@@ -0,0 +1,320 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net"
+	"runtime"
+	"time"
+
+	"github.com/tanema/amore-examples/asteroids/game/netplay"
+)
+
+const (
+	// sessionDelay is how many frames ahead local input is scheduled, enough
+	// for a packet to cross a local network without either end waiting
+	sessionDelay   = 3
+	sessionTimeout = 5 * time.Second
+	helloInterval  = 0.25
+)
+
+// session is a two player round played in lockstep with another instance. The
+// host waits for a Hello and starts the round by sending a Welcome with the seed,
+// mode and ships, the joiner keeps saying Hello until it gets one. From then on
+// both ends only send their input, and check that their state still matches.
+type session struct {
+	conn       *netplay.Conn
+	lock       *netplay.Lockstep
+	host       bool
+	versus     bool
+	seat       int
+	welcome    netplay.Welcome
+	helloTimer float32
+}
+
+// Host waits for another instance to join on addr, then starts a two player
+// round with the ship and mode showing on the start screen. In a versus round
+// the ships can shoot each other, otherwise they work together.
+func (game *Game) Host(addr string, versus bool) error {
+	conn, err := netplay.Listen(addr)
+	if err != nil {
+		return err
+	}
+	game.Reset()
+	game.session = &session{conn: conn, host: true, versus: versus}
+	game.netStatus = fmt.Sprintf("Waiting for a player to join on %v", conn.LocalAddr())
+	return nil
+}
+
+// Join starts a two player round with the instance hosting on addr.
+func (game *Game) Join(addr string) error {
+	conn, err := netplay.Dial(addr)
+	if err != nil {
+		return err
+	}
+	game.Reset()
+	game.session = &session{conn: conn, seat: 1}
+	game.netStatus = fmt.Sprintf("Joining %v", addr)
+	return nil
+}
+
+// arch goes into the rules because the compiler fuses multiplies and adds on
+// some architectures, like arm64, and not on others, like amd64, so the float
+// results and with them the rounds differ between the two.
+var arch = runtime.GOARCH
+
+// rules is a hash of everything besides the input that decides how a round
+// plays out, which both ends need to have the same of.
+func (game *Game) rules() uint32 {
+	hash := fnv.New32a()
+	data, _ := json.Marshal(struct {
+		Ships  []ShipClass
+		Tuning Tuning
+		Arch   string
+	}{game.ships, game.tuning, arch})
+	hash.Write(data)
+	return hash.Sum32()
+}
+
+// seats makes a seat for each end with the ship it asked for.
+func (session *session) seats() []*seat {
+	seats := make([]*seat, maxSeats)
+	for i := range seats {
+		seats[i] = &seat{index: i, lives: startingLives, ship: int(session.welcome.Ships[i])}
+	}
+	return seats
+}
+
+// start begins the round once both ends know the welcome.
+func (game *Game) start(welcome netplay.Welcome) {
+	session := game.session
+	session.welcome = welcome
+	session.lock = netplay.NewLockstep(session.conn, session.seat, int(welcome.Delay))
+	game.mode = Mode(welcome.Mode)
+	game.netStatus = ""
+	game.attract = false
+	game.reset()
+}
+
+// leaveSession says goodbye to the other end and goes back to playing alone,
+// showing why on the start screen.
+func (game *Game) leaveSession(reason string) {
+	session := game.session
+	if session == nil {
+		return
+	}
+	game.session = nil
+	if session.lock != nil {
+		session.lock.Leave()
+	} else {
+		session.conn.Close()
+	}
+	game.netStatus = reason
+}
+
+// endSession leaves the session and starts a single player round.
+func (game *Game) endSession(reason string) {
+	game.leaveSession(reason)
+	game.attract = false
+	game.idle = 0
+	game.reset()
+}
+
+// updateSession runs the network round. Steps are taken at the usual rate but
+// only once both players' input for them has arrived, so a late packet holds
+// the game up rather than letting the two ends drift apart.
+func (game *Game) updateSession(dt float32) {
+	session := game.session
+	if session.lock == nil {
+		game.handshake(dt)
+		return
+	}
+	lock := session.lock
+	for _, msg := range lock.Poll() {
+		if _, hello := msg.(netplay.Hello); hello && session.host {
+			// the joiner missed the welcome
+			session.conn.Send(session.welcome)
+		}
+	}
+	if lock.Left() {
+		game.endSession("The other player left")
+		return
+	}
+	if err := lock.Err(); err != nil {
+		game.endSession(err.Error())
+		return
+	}
+	if lock.Silent() > sessionTimeout {
+		game.endSession("Lost the connection to the other player")
+		return
+	}
+
+	game.accumulator += dt
+	for steps := 0; game.accumulator >= timeStep; steps++ {
+		if steps == maxSteps {
+			game.accumulator = 0
+			break
+		}
+		var input Input
+		if game.controls != nil {
+			input = game.controls.Read()
+		}
+		lock.Push(byte(input))
+		inputs, ok := lock.Next()
+		if !ok {
+			// waiting on the other end, try again next update
+			game.accumulator = min(game.accumulator, timeStep)
+			break
+		}
+		game.accumulator -= timeStep
+		for i, seat := range game.seats {
+			seat.input = Input(inputs[i])
+		}
+		game.simulate()
+		lock.Check(game.checksum())
+	}
+	lock.Flush()
+
+	if frame, desync := lock.Desync(); desync {
+		game.endSession(fmt.Sprintf("Desync at frame %v, the two games no longer match", frame))
+	}
+}
+
+// handshake waits for the other end. The host answers the first Hello with the
+// right rules, the joiner says Hello until it is welcomed or turned away.
+func (game *Game) handshake(dt float32) {
+	session := game.session
+	for _, packet := range session.conn.Poll() {
+		if err, ok := packet.Err.(netplay.VersionError); ok {
+			session.conn.SendTo(netplay.Reject{Reason: err.Error()}, packet.From)
+			continue
+		} else if err, ok := packet.Err.(netplay.ReadError); ok {
+			game.endSession(err.Error())
+			return
+		} else if packet.Err != nil {
+			continue
+		}
+		switch msg := packet.Message.(type) {
+		case netplay.Hello:
+			if !session.host {
+				continue
+			}
+			if reason := game.checkHello(msg); reason != "" {
+				session.conn.SendTo(netplay.Reject{Reason: reason}, packet.From)
+				continue
+			}
+			welcome := netplay.Welcome{
+				Rules:  msg.Rules,
+				Seed:   time.Now().UTC().UnixNano(),
+				Mode:   uint8(game.mode),
+				Versus: session.versus,
+				Delay:  sessionDelay,
+				Ships:  [2]uint8{uint8(game.ship), msg.Ship},
+			}
+			session.conn.SetPeer(packet.From)
+			session.conn.Send(welcome)
+			game.start(welcome)
+			return
+		case netplay.Welcome:
+			if session.host {
+				continue
+			}
+			if msg.Rules != game.rules() || int(msg.Ships[0]) >= len(game.ships) || Mode(msg.Mode) >= modeCount {
+				game.leaveSession("The host has different ships or tuning, or another architecture")
+				return
+			}
+			game.start(msg)
+			return
+		case netplay.Reject:
+			game.leaveSession(fmt.Sprintf("The host turned us away: %v", msg.Reason))
+			return
+		}
+	}
+
+	if session.host {
+		return
+	}
+	if session.helloTimer -= dt; session.helloTimer <= 0 {
+		session.helloTimer = helloInterval
+		session.conn.Send(netplay.Hello{Rules: game.rules(), Ship: uint8(game.ship)})
+	}
+}
+
+func (game *Game) checkHello(hello netplay.Hello) string {
+	if hello.Rules != game.rules() {
+		return "different ships or tuning, or another architecture"
+	}
+	if int(hello.Ship) >= len(game.ships) {
+		return "unknown ship"
+	}
+	return ""
+}
+
+// checksum hashes the state of the round that the two ends of a session have to
+// agree on: the seats and every object's kind and motion.
+func (game *Game) checksum() uint32 {
+	hash := fnv.New32a()
+	var buf [4]byte
+	put := func(v uint32) {
+		buf[0], buf[1], buf[2], buf[3] = byte(v>>24), byte(v>>16), byte(v>>8), byte(v)
+		hash.Write(buf[:])
+	}
+	putFloat := func(v float32) { put(math.Float32bits(v)) }
+	put(uint32(game.frame))
+	put(uint32(game.wave))
+	for _, seat := range game.seats {
+		put(uint32(seat.score))
+		put(uint32(seat.lives))
+	}
+	game.entities.Each(func(id EntityID, object GameObject) {
+		put(uint32(id))
+		put(uint32(object.Kind()))
+		if sprite := spriteOf(object); sprite != nil {
+			putFloat(sprite.x)
+			putFloat(sprite.y)
+			putFloat(sprite.rot)
+			putFloat(sprite.vx)
+			putFloat(sprite.vy)
+		}
+	})
+	return hash.Sum32()
+}
+
+// spriteOf returns the sprite of objects that have one.
+func spriteOf(object GameObject) *Sprite {
+	switch object := object.(type) {
+	case *Player:
+		return object.Sprite
+	case *Asteroid:
+		return object.Sprite
+	case *Bullet:
+		return object.Sprite
+	case *Saucer:
+		return object.Sprite
+	case *Pickup:
+		return object.Sprite
+	}
+	return nil
+}
+
+func (game *Game) drawSession() {
+	if game.netStatus != "" {
+		game.renderer.Print(game.netStatus, game.screenWidth/2-100, game.screenHeight/2-150, 1, 1)
+	}
+	if !game.gameOver || !game.versus {
+		return
+	}
+	for _, seat := range game.seats {
+		if seat.lives > 0 {
+			game.renderer.Print(fmt.Sprintf("Player %v wins", seat.index+1), game.screenWidth/2-100, game.screenHeight/2-140, 1, 1)
+		}
+	}
+}
+
+// localAddr is where the session's socket is bound, for running both ends in
+// one process.
+func (session *session) localAddr() *net.UDPAddr {
+	return session.conn.LocalAddr()
+}
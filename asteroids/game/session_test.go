@@ -0,0 +1,89 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoopback(t *testing.T) {
+	for _, versus := range []bool{false, true} {
+		report := Loopback(800, 600, 30*time.Second, versus)
+		if len(report.Problems) > 0 || report.Frames == 0 {
+			t.Errorf("versus %v: %v", versus, report)
+		}
+	}
+}
+
+// hostAndJoin starts a network round between two games on loopback, each flown
+// by the autopilot. prepare is called with the guest before it joins.
+func hostAndJoin(t *testing.T, prepare func(guest *Game)) (host, guest *Game) {
+	t.Helper()
+	host = New(800, 600, nil, nil, nil)
+	guest = New(800, 600, nil, nil, nil)
+	if prepare != nil {
+		prepare(guest)
+	}
+	host.controls = newAutopilot(host, 0)
+	guest.controls = newAutopilot(guest, 1)
+	if err := host.Host("127.0.0.1:0", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := guest.Join(host.session.localAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		host.Reset()
+		guest.Reset()
+	})
+	return host, guest
+}
+
+func TestSessionDesync(t *testing.T) {
+	host, guest := hostAndJoin(t, nil)
+	bumped := false
+	// once one end finds the desync the other has to notice it too, or that the
+	// first one left
+	for i := 0; i < 20000 && (host.session != nil || guest.session != nil); i++ {
+		host.Update(timeStep)
+		guest.Update(timeStep)
+		if !bumped && guest.session != nil && guest.session.lock != nil && guest.frame > 300 {
+			guest.seats[1].player.x += 0.5
+			bumped = true
+		}
+		time.Sleep(50 * time.Microsecond)
+	}
+	if !bumped {
+		t.Fatalf("the round ended before the state was changed: %q, %q", host.netStatus, guest.netStatus)
+	}
+	if !strings.Contains(host.netStatus, "Desync") && !strings.Contains(guest.netStatus, "Desync") {
+		t.Errorf("the desync wasn't caught: %q, %q", host.netStatus, guest.netStatus)
+	}
+	if host.session != nil || guest.session != nil {
+		t.Error("the round went on after the desync")
+	}
+}
+
+func TestSessionRulesMismatch(t *testing.T) {
+	host, guest := hostAndJoin(t, func(guest *Game) {
+		guest.loadedTuning.BulletSpeed = 1
+	})
+	for i := 0; i < 2000 && guest.session != nil; i++ {
+		host.Update(timeStep)
+		guest.Update(timeStep)
+		time.Sleep(100 * time.Microsecond)
+	}
+	if guest.session != nil || !strings.Contains(guest.netStatus, "tuning") {
+		t.Errorf("a guest with other tuning should be turned away, status %q", guest.netStatus)
+	}
+}
+
+func TestRulesIncludeArchitecture(t *testing.T) {
+	game := New(800, 600, nil, nil, nil)
+	here := game.rules()
+	defer func(saved string) { arch = saved }(arch)
+	arch = "other"
+	if game.rules() == here {
+		t.Error("the rules are the same on another architecture")
+	}
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+)
+
+// newOutline builds a closed, jagged outline around the origin. Every vertex is
+// a random distance between (1-jaggedness) and 1 times the radius out from the
+// center so outlines come out convex or concave depending on the roll.
+func newOutline(rng *rand.Rand, radius, jaggedness float32, vertices int) []float32 {
+	outline := make([]float32, 0, (vertices+1)*2)
+	step := 2 * math.Pi / float64(vertices)
+	for i := 0; i < vertices; i++ {
+		angle := float64(i)*step + (rng.Float64()-0.5)*step*0.5
+		distance := radius * (1 - jaggedness*rng.Float32())
+		outline = append(outline,
+			float32(math.Cos(angle))*distance,
+			float32(math.Sin(angle))*distance)
+	}
+	return append(outline, outline[0], outline[1])
+}
+
+// openPolygon drops the repeated closing vertex of an outline if it has one.
+func openPolygon(points []float32) []float32 {
+	count := len(points)
+	if count > 4 && points[0] == points[count-2] && points[1] == points[count-1] {
+		return points[:count-2]
+	}
+	return points
+}
+
+func closePolygon(points []float32) []float32 {
+	return append(points, points[0], points[1])
+}
+
+// polygonArea returns the area and centroid of a polygon.
+func polygonArea(points []float32) (float32, float32, float32) {
+	points = openPolygon(points)
+	var area, cx, cy float32
+	for i := 0; i < len(points); i += 2 {
+		j := (i + 2) % len(points)
+		cross := points[i]*points[j+1] - points[j]*points[i+1]
+		area += cross
+		cx += (points[i] + points[j]) * cross
+		cy += (points[i+1] + points[j+1]) * cross
+	}
+	if area == 0 {
+		return 0, 0, 0
+	}
+	area /= 2
+	return abs(area), cx / (6 * area), cy / (6 * area)
+}
+
+// clipPolygon keeps the part of an open polygon on one side of the line through
+// x, y heading in dx, dy. Negating the direction keeps the other side.
+func clipPolygon(points []float32, x, y, dx, dy float32) []float32 {
+	side := func(px, py float32) float32 {
+		return dx*(py-y) - dy*(px-x)
+	}
+
+	clipped := []float32{}
+	for i := 0; i < len(points); i += 2 {
+		j := (i + 2) % len(points)
+		ax, ay, bx, by := points[i], points[i+1], points[j], points[j+1]
+		sa, sb := side(ax, ay), side(bx, by)
+		if sa <= 0 {
+			clipped = append(clipped, ax, ay)
+		}
+		if (sa < 0 && sb > 0) || (sa > 0 && sb < 0) {
+			t := sa / (sa - sb)
+			clipped = append(clipped, ax+(bx-ax)*t, ay+(by-ay)*t)
+		}
+	}
+	if len(clipped) < 6 {
+		return nil
+	}
+	return clipped
+}
+
+// polygonInertia returns the second moment of area of a polygon about the
+// origin, which times density is its moment of inertia when spun around it.
+func polygonInertia(points []float32) float32 {
+	points = openPolygon(points)
+	var inertia float32
+	for i := 0; i < len(points); i += 2 {
+		j := (i + 2) % len(points)
+		x0, y0, x1, y1 := points[i], points[i+1], points[j], points[j+1]
+		cross := x0*y1 - x1*y0
+		inertia += cross * (x0*x0 + x0*x1 + x1*x1 + y0*y0 + y0*y1 + y1*y1)
+	}
+	return abs(inertia / 12)
+}
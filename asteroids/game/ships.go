@@ -0,0 +1,174 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ShipClass is a ship the player can fly, as loaded from the ship data file.
+// The outline starts with the left rear corner, the nose and the right rear
+// corner, the jet is drawn between the two rear corners.
+type ShipClass struct {
+	Name       string     `json:"name"`
+	Outline    []float32  `json:"outline"`
+	Thrust     float32    `json:"thrust"`
+	MaxSpeed   float32    `json:"maxSpeed"`
+	Drag       float32    `json:"drag"`
+	TurnRate   float32    `json:"turnRate"`
+	Weapon     Weapon     `json:"weapon"`
+	Hyperspace Hyperspace `json:"hyperspace"`
+}
+
+// Weapon is how fast a ship fires and how many bullets each shot fans out into.
+type Weapon struct {
+	FireRate float32 `json:"fireRate"`
+	Shots    int     `json:"shots"`
+}
+
+// Hyperspace jumps the ship to a random spot on the screen. After each jump it
+// has to cool down, and every jump has a chance of destroying the ship.
+type Hyperspace struct {
+	Cooldown   float32 `json:"cooldown"`
+	FailChance float32 `json:"failChance"`
+}
+
+// defaultShips is used when no ship file is loaded.
+var defaultShips = []ShipClass{{
+	Name:       "Classic",
+	Outline:    []float32{-5, 4, 0, -12, 5, 4, -5, 4},
+	Thrust:     200,
+	MaxSpeed:   400,
+	TurnRate:   6,
+	Weapon:     Weapon{FireRate: 0.4, Shots: 1},
+	Hyperspace: Hyperspace{Cooldown: 5, FailChance: 0.1},
+}}
+
+// LoadShips reads a JSON list of ship classes and checks that each one can be
+// flown.
+func LoadShips(path string) ([]ShipClass, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ships := []ShipClass{}
+	if err := json.Unmarshal(data, &ships); err != nil {
+		return nil, err
+	}
+	if len(ships) == 0 {
+		return nil, fmt.Errorf("%v: no ships defined", path)
+	}
+	for _, ship := range ships {
+		if err := ship.validate(); err != nil {
+			return nil, fmt.Errorf("%v: ship %q: %v", path, ship.Name, err)
+		}
+	}
+	return ships, nil
+}
+
+func (ship ShipClass) validate() error {
+	switch {
+	case len(ship.Outline) < 6 || len(ship.Outline)%2 != 0:
+		return fmt.Errorf("outline needs at least 3 points")
+	case ship.Thrust <= 0 || ship.MaxSpeed <= 0 || ship.TurnRate <= 0:
+		return fmt.Errorf("thrust, maxSpeed and turnRate must be positive")
+	case ship.Drag < 0:
+		return fmt.Errorf("drag can't be negative")
+	case ship.Weapon.FireRate <= 0 || ship.Weapon.Shots < 1:
+		return fmt.Errorf("weapon needs a positive fireRate and at least one shot")
+	case ship.Hyperspace.Cooldown < 0 || ship.Hyperspace.FailChance < 0 || ship.Hyperspace.FailChance > 1:
+		return fmt.Errorf("hyperspace needs a cooldown of at least 0 and a failChance between 0 and 1")
+	}
+	return nil
+}
+
+// SetShips replaces the ships the player can pick from and starts a new round
+// so that one can be picked.
+func (game *Game) SetShips(ships []ShipClass) {
+	game.loadedShips = ships
+	game.ship = 0
+	game.Reset()
+}
+
+// WatchShips loads the ship file and keeps checking it for changes the same way
+// as WatchTuning. Ships already flying keep their class until they respawn.
+func (game *Game) WatchShips(path string) {
+	game.shipsFile = &watchedFile{path: path}
+	game.reloadShips()
+}
+
+func (game *Game) reloadShips() {
+	file := game.shipsFile
+	if file == nil || !file.changed() {
+		return
+	}
+	ships, err := LoadShips(file.path)
+	if file.err = err; err != nil {
+		return
+	}
+	game.loadedShips = ships
+	if !game.holdingData() {
+		game.useShips(ships)
+	}
+}
+
+// useShips switches to the ships, keeping the picked ones if they are still
+// there.
+func (game *Game) useShips(ships []ShipClass) {
+	game.ships = ships
+	if game.ship >= len(ships) {
+		game.ship = 0
+	}
+	for _, seat := range game.seats {
+		if seat.ship >= len(ships) {
+			seat.ship = 0
+		}
+	}
+}
+
+// updateChoice lets the player cycle through the ships with left and right and
+// the modes with up and down before the round starts, and launches with the
+// ones showing when they fire.
+func (game *Game) updateChoice() {
+	seat := game.seats[0]
+	switch {
+	case seat.pressed(InputFire):
+		game.choosing = false
+		game.launch()
+	case seat.pressed(InputLeft):
+		game.ship = (game.ship + len(game.ships) - 1) % len(game.ships)
+	case seat.pressed(InputRight):
+		game.ship = (game.ship + 1) % len(game.ships)
+	case seat.pressed(InputShield):
+		game.mode = (game.mode + modeCount - 1) % modeCount
+	case seat.pressed(InputThrust):
+		game.mode = (game.mode + 1) % modeCount
+	}
+}
+
+// launch puts every seat's ship on screen and starts the first wave. A single
+// player flies the ship picked on the start screen.
+func (game *Game) launch() {
+	if game.session == nil {
+		game.seats[0].ship = game.ship
+	}
+	for _, seat := range game.seats {
+		game.spawnPlayer(seat)
+	}
+	game.startWave()
+}
+
+func (game *Game) drawChoice() {
+	ship := game.ships[game.ship]
+	x, y := game.screenWidth/2, game.screenHeight/2
+	preview := make([]float32, len(ship.Outline))
+	for i := 0; i < len(preview); i += 2 {
+		preview[i], preview[i+1] = x+ship.Outline[i]*4, y-60+ship.Outline[i+1]*4
+	}
+	game.renderer.PolyLine(preview)
+	game.renderer.Print(ship.Name, x-100, y, 2, 2)
+	game.renderer.Print(fmt.Sprintf("thrust %v  top speed %v  turn %v", ship.Thrust, ship.MaxSpeed, ship.TurnRate), x-100, y+30, 1, 1)
+	game.renderer.Print(fmt.Sprintf("fires %v every %vs  hyperspace every %vs", ship.Weapon.Shots, ship.Weapon.FireRate, ship.Hyperspace.Cooldown), x-100, y+45, 1, 1)
+	game.renderer.Print(fmt.Sprintf("Mode: %v - %v", game.mode, modes[game.mode].summary), x-100, y+75, 1, 1)
+	game.renderer.Print("Left and right to choose a ship, up and down a mode, fire to launch", x-100, y+105, 1, 1)
+}
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// SoakReport is what happened while the autopilot played for a while.
+type SoakReport struct {
+	Played    time.Duration
+	Rounds    int
+	BestScore int
+	Score     int
+	Deaths    int
+	// Panics holds each panic with the seed of the round it happened in.
+	Panics []string
+	// Leaks holds every time the world had bodies that no object owns.
+	Leaks []string
+}
+
+func (report SoakReport) String() string {
+	lines := []string{
+		fmt.Sprintf("played %v over %v rounds", report.Played, report.Rounds),
+		fmt.Sprintf("score %v in total, best round %v", report.Score, report.BestScore),
+		fmt.Sprintf("deaths %v", report.Deaths),
+		fmt.Sprintf("panics %v", len(report.Panics)),
+	}
+	lines = append(lines, report.Panics...)
+	lines = append(lines, fmt.Sprintf("leaks %v", len(report.Leaks)))
+	lines = append(lines, report.Leaks...)
+	return strings.Join(lines, "\n")
+}
+
+// Soak lets the autopilot play without a window for the given amount of game
+// time, as fast as it can, starting a new round in the next mode whenever one
+// ends. Panics are recovered and reported so the run carries on, and the
+// physics world is checked for leaked bodies after every step.
+func Soak(width, height float32, duration time.Duration) SoakReport {
+	game := New(width, height, nil, nil, nil)
+	game.controls = game.autopilot
+	report := SoakReport{}
+	steps := int(math.Round(duration.Seconds() / float64(timeStep)))
+
+	endRound := func() {
+		report.Rounds++
+		game.mode = Mode(report.Rounds) % modeCount
+		seat := game.seats[0]
+		report.Score += seat.score
+		report.Deaths += seat.deaths
+		if seat.score > report.BestScore {
+			report.BestScore = seat.score
+		}
+	}
+
+	for i := 0; i < steps; i++ {
+		if game.gameOver {
+			endRound()
+			game.Reset()
+		}
+		if err := game.soakStep(); err != "" {
+			report.Panics = append(report.Panics, fmt.Sprintf("seed %v frame %v: %v", game.seed, game.frame, err))
+			endRound()
+			game.Reset()
+			continue
+		}
+		if leaked := game.world.Count() - game.ownedBodies(); leaked != 0 {
+			report.Leaks = append(report.Leaks, fmt.Sprintf("seed %v frame %v: %v bodies", game.seed, game.frame, leaked))
+		}
+	}
+	endRound()
+	report.Played = time.Duration(float64(steps) * float64(timeStep) * float64(time.Second)).Round(time.Millisecond)
+	return report
+}
+
+func (game *Game) soakStep() (err string) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Sprintf("%v\n%s", r, debug.Stack())
+		}
+	}()
+	game.step()
+	return ""
+}
+
+// ownedBodies counts the collidable bodies that live objects should have in the
+// world.
+func (game *Game) ownedBodies() int {
+	owned := 0
+	game.entities.Each(func(id EntityID, object GameObject) {
+		if kind := object.Kind(); kind != KindExplosion && kind != KindWell {
+			owned++
+		}
+	})
+	return owned
+}
@@ -1,59 +1,106 @@
 package game
 
 import (
-	"github.com/tanema/amore/gfx"
-
 	"github.com/tanema/amore-examples/asteroids/game/phys"
 )
 
 type Sprite struct {
+	game         *Game
 	name         string
 	body         *phys.Body
 	scale        float32
 	x, y, rot    float32
 	vx, vy, vrot float32
 	ax, ay       float32
+	mass         float32
+	inertia      float32
+	restitution  float32
 	wraps        bool
+	// ghost is reused to draw the outline across the edges each frame
+	ghost []float32
 }
 
-func NewSprite(collidable phys.Collidable, name string, x, y, scale float32, points []float32, wraps bool) *Sprite {
+func NewSprite(game *Game, collidable phys.Collidable, name string, x, y, scale float32, points []float32, wraps bool) *Sprite {
 	new_sprite := &Sprite{
+		game:  game,
 		name:  name,
-		body:  world.AddBody(collidable, name, x, y, scale, points),
+		body:  game.world.AddBody(collidable, name, x, y, scale, points),
 		x:     x,
 		y:     y,
 		scale: scale,
 		wraps: wraps,
 	}
+	// mass and inertia come from the outline with a density of one
+	area, _, _ := polygonArea(points)
+	new_sprite.mass = area * scale * scale
+	new_sprite.inertia = polygonInertia(points) * scale * scale * scale * scale
+	new_sprite.body.SetWraps(wraps)
 	return new_sprite
 }
 
-func (sprite *Sprite) UpdateMovement(delta float32) []*phys.Body {
+func (sprite *Sprite) UpdateMovement(delta float32) []*phys.Contact {
 	sprite.vx += sprite.ax * delta
 	sprite.vy += sprite.ay * delta
 	dx, dy, dr := sprite.vx*delta, sprite.vy*delta, sprite.vrot*delta
 	sprite.x, sprite.y, sprite.rot = sprite.x+dx, sprite.y+dy, sprite.rot+dr
-	collisions := sprite.body.Move(sprite.x, sprite.y, sprite.rot, sprite.scale)
 	if sprite.wraps {
-		if sprite.x > screenWidth {
-			sprite.x = 0
-		} else if sprite.x < 0 {
-			sprite.x = screenWidth
-		}
-		if sprite.y > screenHeight {
-			sprite.y = 0
-		} else if sprite.y < 0 {
-			sprite.y = screenHeight
-		}
+		sprite.x = wrap(sprite.x, sprite.game.screenWidth)
+		sprite.y = wrap(sprite.y, sprite.game.screenHeight)
 	}
+	collisions := sprite.body.Move(sprite.x, sprite.y, sprite.rot, sprite.scale)
 	return collisions
 }
 
+// Draw outlines every shape of the sprite's body, and again across each edge it
+// is wrapping over.
 func (sprite *Sprite) Draw() {
-	if debug {
-		sprite.body.Draw()
+	if sprite.game.debug {
+		sprite.body.Draw(sprite.game.renderer)
+	}
+	sprite.drawShapes(0, 0)
+	sprite.body.EachGhost(sprite.drawShapes)
+}
+
+func (sprite *Sprite) drawShapes(dx, dy float32) {
+	renderer := sprite.game.renderer
+	sprite.body.EachShape(func(points []float32, radius float32) {
+		if radius > 0 {
+			renderer.Circle(points[0]+dx, points[1]+dy, radius)
+			return
+		}
+		if dx == 0 && dy == 0 {
+			renderer.PolyLine(points)
+			return
+		}
+		sprite.ghost = sprite.ghost[:0]
+		for i := 0; i < len(points); i += 2 {
+			sprite.ghost = append(sprite.ghost, points[i]+dx, points[i+1]+dy)
+		}
+		renderer.PolyLine(sprite.ghost)
+	})
+}
+
+// wrap keeps v inside 0..size, carrying over how far it went past the edge so
+// that sprites slide across instead of jumping.
+func wrap(v, size float32) float32 {
+	for v >= size {
+		v -= size
+	}
+	for v < 0 {
+		v += size
+	}
+	return v
+}
+
+// nearest turns the distance d between two points into the shortest one around
+// a playfield that wraps every size.
+func nearest(d, size float32) float32 {
+	if d > size/2 {
+		return d - size
+	} else if d < -size/2 {
+		return d + size
 	}
-	gfx.PolyLine(sprite.body.GetPoints())
+	return d
 }
 
 func (sprite *Sprite) GetPoints() []float32 {
@@ -0,0 +1,56 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/tanema/amore-examples/asteroids/game/phys"
+)
+
+// tally counts what is drawn instead of drawing it.
+type tally struct {
+	polyLines, circles int
+	left               float32
+}
+
+func (tally *tally) SetColor(r, g, b, a float32)                     {}
+func (tally *tally) Line(x0, y0, x1, y1 float32)                     {}
+func (tally *tally) Rect(x, y, width, height float32)                {}
+func (tally *tally) Print(text string, x, y, scaleX, scaleY float32) {}
+
+func (tally *tally) PolyLine(points []float32) {
+	tally.polyLines++
+	for i := 0; i < len(points); i += 2 {
+		if points[i] < tally.left {
+			tally.left = points[i]
+		}
+	}
+}
+
+func (tally *tally) Circle(x, y, radius float32) {
+	tally.circles++
+}
+
+type inert struct{}
+
+func (inert) Destroy(bool) {}
+
+func TestSpriteDraw(t *testing.T) {
+	drawn := &tally{}
+	game := New(800, 600, nil, nil, drawn)
+	// a hull and a turret straddling the right edge, so drawn twice
+	sprite := &Sprite{game: game, wraps: true}
+	sprite.body = game.world.AddShapes(inert{}, "ship", 795, 300, 1,
+		phys.Polygon(-10, -10, 10, -10, 10, 10, -10, 10), phys.Circle(0, 0, 6))
+	sprite.body.SetWraps(true)
+
+	sprite.Draw()
+	if drawn.polyLines != 2 || drawn.circles != 2 {
+		t.Errorf("drew %v outlines and %v circles", drawn.polyLines, drawn.circles)
+	}
+	if drawn.left > 0 {
+		t.Errorf("the ghost across the edge wasn't drawn, leftmost point %v", drawn.left)
+	}
+	if allocs := testing.AllocsPerRun(100, sprite.Draw); allocs != 0 {
+		t.Errorf("drawing allocated %v times", allocs)
+	}
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// tuningInterval is how often, in seconds, the tuning file is checked for
+// changes.
+const tuningInterval = 0.5
+
+// Tuning is every balance number that can be changed while the game is running.
+// Most apply from the next time they are used, CellSize only from the next
+// round. Changes made in the middle of a recorded, replayed or network round
+// wait for the next one, as replays keep the tuning they were recorded with.
+type Tuning struct {
+	AsteroidSpeed      float32 `json:"asteroidSpeed"`
+	AsteroidSpin       float32 `json:"asteroidSpin"`
+	BulletSpeed        float32 `json:"bulletSpeed"`
+	CellSize           float32 `json:"cellSize"`
+	ExplosionSpeed     float32 `json:"explosionSpeed"`
+	PlayerInvulnerable float32 `json:"playerInvulnerable"`
+	PlayerBlinkRate    float32 `json:"playerBlinkRate"`
+	PlayerShieldTime   float32 `json:"playerShieldTime"`
+	PlayerShieldCharge float32 `json:"playerShieldCharge"`
+	PlayerShieldRadius float32 `json:"playerShieldRadius"`
+}
+
+var defaultTuning = Tuning{
+	AsteroidSpeed:      100,
+	AsteroidSpin:       2,
+	BulletSpeed:        500,
+	CellSize:           60,
+	ExplosionSpeed:     100,
+	PlayerInvulnerable: 3,
+	PlayerBlinkRate:    10,
+	PlayerShieldTime:   3,
+	PlayerShieldCharge: 0.25,
+	PlayerShieldRadius: 16,
+}
+
+// LoadTuning reads a JSON tuning file. Anything the file leaves out keeps its
+// default, while unknown names and bad values are errors.
+func LoadTuning(path string) (Tuning, error) {
+	tuning := defaultTuning
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return tuning, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&tuning); err != nil {
+		return defaultTuning, fmt.Errorf("%v: %v", path, err)
+	}
+	if err := tuning.validate(); err != nil {
+		return defaultTuning, fmt.Errorf("%v: %v", path, err)
+	}
+	return tuning, nil
+}
+
+func (tuning Tuning) validate() error {
+	problems := []string{}
+	positive := func(name string, value float32) {
+		if value <= 0 {
+			problems = append(problems, fmt.Sprintf("%v must be more than 0, not %v", name, value))
+		}
+	}
+	notNegative := func(name string, value float32) {
+		if value < 0 {
+			problems = append(problems, fmt.Sprintf("%v can't be negative, not %v", name, value))
+		}
+	}
+	notNegative("asteroidSpeed", tuning.AsteroidSpeed)
+	notNegative("asteroidSpin", tuning.AsteroidSpin)
+	positive("bulletSpeed", tuning.BulletSpeed)
+	positive("cellSize", tuning.CellSize)
+	notNegative("explosionSpeed", tuning.ExplosionSpeed)
+	notNegative("playerInvulnerable", tuning.PlayerInvulnerable)
+	positive("playerBlinkRate", tuning.PlayerBlinkRate)
+	positive("playerShieldTime", tuning.PlayerShieldTime)
+	notNegative("playerShieldCharge", tuning.PlayerShieldCharge)
+	positive("playerShieldRadius", tuning.PlayerShieldRadius)
+	if len(problems) > 0 {
+		return fmt.Errorf("%v", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// watchedFile is a data file that is checked for changes while the game runs.
+type watchedFile struct {
+	path    string
+	modTime time.Time
+	// why the file last couldn't be used, shown on screen until it is fixed
+	err error
+}
+
+// changed reports if the file was modified since the last time it was checked.
+func (file *watchedFile) changed() bool {
+	info, err := os.Stat(file.path)
+	if err != nil {
+		file.err = err
+		return false
+	}
+	if info.ModTime().Equal(file.modTime) {
+		return false
+	}
+	file.modTime = info.ModTime()
+	return true
+}
+
+// WatchTuning loads the tuning file and keeps checking it for changes so edits
+// apply to the running game. If the file can't be used the last good tuning is
+// kept and the problem is shown on screen until the file is fixed.
+func (game *Game) WatchTuning(path string) {
+	game.tuningFile = &watchedFile{path: path}
+	game.reloadTuning()
+}
+
+// updateWatched reloads the watched data files every tuningInterval seconds of
+// real time if they have changed.
+func (game *Game) updateWatched(dt float32) {
+	if game.watchTimer += dt; game.watchTimer < tuningInterval {
+		return
+	}
+	game.watchTimer = 0
+	game.reloadTuning()
+	game.reloadShips()
+}
+
+// holdingData is true while the round has to play out with the data it started
+// with: while it is recorded or played back, or played over the network.
+// Reloaded data waits for the next round.
+func (game *Game) holdingData() bool {
+	return game.recording != nil || game.playback != nil || game.session != nil
+}
+
+func (game *Game) reloadTuning() {
+	file := game.tuningFile
+	if file == nil || !file.changed() {
+		return
+	}
+	tuning, err := LoadTuning(file.path)
+	if file.err = err; err != nil {
+		return
+	}
+	game.loadedTuning = tuning
+	if !game.holdingData() {
+		game.tuning = tuning
+	}
+}
+
+func (game *Game) drawDataErrors() {
+	y := game.screenHeight - 15
+	for _, file := range []*watchedFile{game.shipsFile, game.tuningFile} {
+		if file == nil || file.err == nil {
+			continue
+		}
+		game.renderer.SetColor(255, 80, 80, 255)
+		game.renderer.Print(fmt.Sprintf("%v: %v", file.path, file.err), 0, y, 1, 1)
+		y -= 15
+	}
+	game.renderer.SetColor(255, 255, 255, 255)
+}
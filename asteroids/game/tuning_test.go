@@ -0,0 +1,131 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rewrite writes a data file with a modification time that is bumped each time
+// so that the change is noticed however fast the test runs.
+func rewrite(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	next := time.Now()
+	if info, err := os.Stat(path); err == nil && !info.ModTime().Before(next) {
+		next = info.ModTime()
+	}
+	next = next.Add(time.Second)
+	if err := os.Chtimes(path, next, next); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTuning(t *testing.T) {
+	tuning, err := LoadTuning("../assets/tuning.json")
+	if err != nil || tuning != defaultTuning {
+		t.Errorf("the tuning file should hold the defaults, got %+v, %v", tuning, err)
+	}
+	path := filepath.Join(t.TempDir(), "tuning.json")
+	rewrite(t, path, `{"bulletSpeed": -1, "cellSize": 0, "bogus": 1}`)
+	if _, err := LoadTuning(path); err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("unknown names should be an error, got %v", err)
+	}
+	rewrite(t, path, `{"bulletSpeed": -1, "cellSize": 0}`)
+	if _, err := LoadTuning(path); err == nil || !strings.Contains(err.Error(), "bulletSpeed") || !strings.Contains(err.Error(), "cellSize") {
+		t.Errorf("every bad value should be reported, got %v", err)
+	}
+}
+
+func TestWatchTuning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuning.json")
+	rewrite(t, path, `{"bulletSpeed": 900}`)
+	game := New(800, 600, nil, nil, nil)
+	game.WatchTuning(path)
+	if game.tuning.BulletSpeed != 900 || game.tuningFile.err != nil {
+		t.Fatalf("tuning %+v, %v", game.tuning, game.tuningFile.err)
+	}
+
+	rewrite(t, path, `{"bulletSpeed": -1}`)
+	game.Update(tuningInterval)
+	if game.tuning.BulletSpeed != 900 || game.tuningFile.err == nil {
+		t.Errorf("a bad file should keep the last good tuning and report why, got %+v, %v", game.tuning, game.tuningFile.err)
+	}
+
+	rewrite(t, path, `{"asteroidSpin": 5}`)
+	game.Update(tuningInterval)
+	if game.tuningFile.err != nil || game.tuning.AsteroidSpin != 5 || game.tuning.BulletSpeed != defaultTuning.BulletSpeed {
+		t.Errorf("the fixed file should apply, got %+v, %v", game.tuning, game.tuningFile.err)
+	}
+}
+
+func TestWatchShips(t *testing.T) {
+	data, err := os.ReadFile("../assets/ships.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ships.json")
+	rewrite(t, path, string(data))
+	game := New(800, 600, nil, nil, nil)
+	game.WatchShips(path)
+	if len(game.ships) < 2 || game.shipsFile.err != nil {
+		t.Fatalf("%v ships, %v", len(game.ships), game.shipsFile.err)
+	}
+	game.ship = len(game.ships) - 1
+
+	rewrite(t, path, `[{"name": "Brick", "outline": [0, 0, 1, 1], "thrust": 1, "maxSpeed": 1, "weapon": {"fireRate": 1, "shots": 0}}]`)
+	game.Update(tuningInterval)
+	if len(game.ships) < 2 || game.shipsFile.err == nil {
+		t.Errorf("a bad file should keep the last good ships and report why, got %v ships, %v", len(game.ships), game.shipsFile.err)
+	}
+
+	rewrite(t, path, `[{"name": "Only", "outline": [-5, 4, 0, -12, 5, 4, -5, 4], "thrust": 100, "maxSpeed": 200, "turnRate": 5, "weapon": {"fireRate": 0.5, "shots": 1}}]`)
+	game.Update(tuningInterval)
+	if len(game.ships) != 1 || game.ships[0].Name != "Only" || game.ship != 0 || game.shipsFile.err != nil {
+		t.Errorf("the fixed file should apply and keep the picked ship in range, got %+v, ship %v, %v", game.ships, game.ship, game.shipsFile.err)
+	}
+}
+
+func TestReplayKeepsData(t *testing.T) {
+	dir := t.TempDir()
+	tuningPath, replayPath := filepath.Join(dir, "tuning.json"), filepath.Join(dir, "round.json")
+	rewrite(t, tuningPath, `{"bulletSpeed": 900}`)
+	game := New(800, 600, &scripted{}, nil, nil)
+	game.WatchTuning(tuningPath)
+	game.Record(replayPath)
+	play(game, 60)
+
+	// edits made while recording wait for the next round
+	rewrite(t, tuningPath, `{"bulletSpeed": 300}`)
+	game.Update(tuningInterval)
+	if game.tuning.BulletSpeed != 900 {
+		t.Errorf("the tuning changed in the middle of a recorded round")
+	}
+	game.Stop()
+
+	replay, err := LoadReplay(replayPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Tuning.BulletSpeed != 900 || len(replay.Ships) != len(defaultShips) {
+		t.Fatalf("the replay holds tuning %+v and %v ships", replay.Tuning, len(replay.Ships))
+	}
+	playback := New(800, 600, nil, nil, nil)
+	playback.WatchTuning(tuningPath)
+	playback.Play(replay)
+	if playback.tuning.BulletSpeed != 900 {
+		t.Errorf("the replay played with tuning %+v instead of its own", playback.tuning)
+	}
+
+	replay.Version = 1
+	if err := replay.Save(replayPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadReplay(replayPath); err == nil {
+		t.Error("a replay from before the data was kept should be refused")
+	}
+}
@@ -0,0 +1,140 @@
+package game
+
+const (
+	startingLives     = 3
+	extraLifeScore    = 10000
+	baseWaveAsteroids = 4
+	maxWaveAsteroids  = 11
+	waveSpeedUp       = 0.1
+	waveDelay         = 2
+	respawnDelay      = 2
+	respawnSafeRadius = 100
+	// respawnDangers are what keep a spawn point from being clear. Teammates,
+	// their shots and pickups don't, or they could hold a respawn up forever.
+	respawnDangers = categoryAsteroid | categorySaucer | categorySaucerBullet
+)
+
+// startWave spawns the next wave along the edges of the screen. Every wave has
+// two more asteroids than the last, up to maxWaveAsteroids, and they get faster.
+// In the gravity mode the wells are moved first and the asteroids start out
+// orbiting them, one more each wave up to gravityMaxAsteroids.
+func (game *Game) startWave() {
+	game.wave++
+	if game.mode == ModeGravity {
+		game.placeWells()
+	}
+	for i := 0; i < game.waveAsteroids(); i++ {
+		asteroid := newAsteroid(game)
+		if game.mode == ModeGravity {
+			game.orbit(asteroid.Sprite)
+		}
+		game.entities.Spawn(asteroid)
+	}
+}
+
+// waveAsteroids is how many large asteroids the current wave starts with.
+func (game *Game) waveAsteroids() int {
+	base, step, most := baseWaveAsteroids, 2, maxWaveAsteroids
+	if game.mode == ModeGravity {
+		base, step, most = gravityBaseAsteroids, 1, gravityMaxAsteroids
+	}
+	count := base + (game.wave-1)*step
+	if count > most {
+		count = most
+	}
+	return count
+}
+
+func (game *Game) waveSpeed() float32 {
+	return 1 + float32(game.wave-1)*waveSpeedUp
+}
+
+func (game *Game) updateWave(dt float32) {
+	if game.entities.Count(KindAsteroid) > 0 {
+		return
+	}
+	if game.waveTimer == 0 && game.mode == ModeGravity {
+		// surviving a wave among the wells is worth more the later it is
+		for _, seat := range game.seats {
+			if seat.player != nil {
+				game.addScore(seat, gravityWaveBonus*game.wave)
+			}
+		}
+	}
+	game.waveTimer += dt
+	if game.waveTimer >= waveDelay {
+		game.waveTimer = 0
+		game.startWave()
+	}
+}
+
+// addScore adds points to the seat and hands out an extra life every
+// extraLifeScore points.
+func (game *Game) addScore(seat *seat, points int) {
+	before := seat.score / extraLifeScore
+	seat.score += points
+	if seat.score/extraLifeScore > before {
+		seat.lives++
+	}
+}
+
+// playerDied takes a life from the seat. Working together the round is over
+// once nobody has any left, in a versus round as soon as anyone runs out.
+func (game *Game) playerDied(seat *seat) {
+	seat.player = nil
+	seat.lives--
+	seat.deaths++
+	out := 0
+	for _, seat := range game.seats {
+		if seat.lives <= 0 {
+			out++
+		}
+	}
+	if out == len(game.seats) || (game.versus && out > 0) {
+		game.gameOver = true
+		game.checkHighScore()
+	}
+}
+
+// updateRespawn brings each seat's ship back once it has been dead for
+// respawnDelay and its spawn point is clear.
+func (game *Game) updateRespawn(dt float32) {
+	if game.gameOver {
+		return
+	}
+	for _, seat := range game.seats {
+		if seat.player != nil || seat.lives <= 0 {
+			continue
+		}
+		seat.respawnTimer += dt
+		if seat.respawnTimer < respawnDelay {
+			continue
+		}
+		if !game.spawnClear(game.spawnPoint(seat)) {
+			continue
+		}
+		seat.respawnTimer = 0
+		game.spawnPlayer(seat)
+	}
+}
+
+// spawnClear reports if nothing that could hurt a new ship is near x, y, which
+// in a versus round includes the other ship's bullets.
+func (game *Game) spawnClear(x, y float32) bool {
+	dangers := respawnDangers
+	if game.versus {
+		dangers |= categoryBullet
+	}
+	for _, body := range game.world.QueryCircle(x, y, respawnSafeRadius) {
+		if body.Category&dangers != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// spawnPlayer puts a new ship on screen for the seat.
+func (game *Game) spawnPlayer(seat *seat) {
+	seat.player = newPlayer(game, seat)
+	game.entities.Spawn(seat.player)
+}
@@ -0,0 +1,43 @@
+package game
+
+import "testing"
+
+// coop starts a two seat round with no asteroids left in it.
+func coop() *Game {
+	game := New(800, 600, nil, nil, nil)
+	game.seats = []*seat{{lives: startingLives}, {index: 1, lives: startingLives}}
+	game.choosing = false
+	game.launch()
+	game.entities.EachKind(KindAsteroid, func(id EntityID, object GameObject) {
+		object.(*Asteroid).Destroy(true)
+	})
+	game.entities.flush()
+	return game
+}
+
+func TestRespawnNextToTeammate(t *testing.T) {
+	// long enough to respawn with a little to spare
+	delay := float32(respawnDelay)
+	steps := int(delay/timeStep) + 30
+	game := coop()
+	game.seats[1].player.Destroy(false)
+	for i := 0; i < steps && game.seats[1].player == nil; i++ {
+		game.simulate()
+	}
+	if game.seats[1].player == nil {
+		t.Error("a teammate sitting on the start kept the dead player from coming back")
+	}
+
+	// an asteroid on the spawn point does hold it up
+	game = coop()
+	game.seats[1].player.Destroy(false)
+	x, y := game.spawnPoint(game.seats[1])
+	class := asteroidClasses[asteroidSmall]
+	game.entities.Spawn(spawnAsteroid(game, asteroidSmall, x+40, y, newOutline(game.rng, class.radius, 0, class.vertices)))
+	for i := 0; i < steps; i++ {
+		game.simulate()
+	}
+	if game.seats[1].player != nil {
+		t.Error("the player came back on top of an asteroid")
+	}
+}
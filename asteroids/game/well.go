@@ -0,0 +1,206 @@
+package game
+
+import (
+	"math"
+)
+
+const (
+	gravityBaseAsteroids = 3
+	gravityMaxAsteroids  = 8
+	gravityMaxWells      = 3
+	gravityWaveBonus     = 500
+	wellSafeRadius       = 180
+	wellSpacing          = 220
+	wellPlaceTries       = 50
+	wellBonusRange       = 4
+	wellBonusMultiplier  = 2
+	wellDrawSegments     = 24
+)
+
+type wellKind int
+
+const (
+	wellBlackHole wellKind = iota
+	wellStar
+)
+
+// wellClasses is how hard each kind of well pulls, the radius of its event
+// horizon and the colour it is drawn in. The pull at a distance d is
+// strength/d², so a circular orbit at d has a speed of sqrt(strength/d).
+var wellClasses = []struct {
+	strength float32
+	horizon  float32
+	r, g, b  float32
+}{
+	wellBlackHole: {strength: 2500000, horizon: 16, r: 170, g: 120, b: 255},
+	wellStar:      {strength: 1500000, horizon: 30, r: 255, g: 210, b: 90},
+}
+
+// Well is a black hole or a star in the gravity mode. It stays put and pulls on
+// ships, bullets and asteroids, and destroys anything that reaches its event
+// horizon.
+type Well struct {
+	game *Game
+	kind wellKind
+	x, y float32
+	spin float32
+}
+
+func (well *Well) Kind() Kind {
+	return KindWell
+}
+
+func (well *Well) Update(dt float32) {
+	well.spin += dt
+}
+
+func (well *Well) Draw() {
+	class := wellClasses[well.kind]
+	renderer := well.game.renderer
+	renderer.SetColor(class.r, class.g, class.b, 255)
+	renderer.PolyLine(circlePoints(well.x, well.y, class.horizon, 0))
+	switch well.kind {
+	case wellBlackHole:
+		// a faint accretion disk turning around the horizon
+		renderer.SetColor(class.r, class.g, class.b, 90)
+		renderer.PolyLine(circlePoints(well.x, well.y, class.horizon*2, well.spin))
+	case wellStar:
+		for i := 0; i < 8; i++ {
+			angle := float32(i)*math.Pi/4 + well.spin/4
+			inner, outer := class.horizon+4, class.horizon+8+4*sin(well.spin*3+float32(i))
+			renderer.Line(well.x+sin(angle)*inner, well.y-cos(angle)*inner, well.x+sin(angle)*outer, well.y-cos(angle)*outer)
+		}
+	}
+	renderer.SetColor(255, 255, 255, 255)
+}
+
+func (well *Well) Destroy(force bool) {
+	well.game.entities.Despawn(well)
+}
+
+// circlePoints is a closed outline of a circle, starting at the given angle.
+func circlePoints(x, y, radius, start float32) []float32 {
+	points := make([]float32, 0, (wellDrawSegments+1)*2)
+	for i := 0; i <= wellDrawSegments; i++ {
+		angle := start + float32(i)*2*math.Pi/wellDrawSegments
+		points = append(points, x+sin(angle)*radius, y-cos(angle)*radius)
+	}
+	return points
+}
+
+// placeWells replaces the wells with a new set for the wave, one more every
+// other wave up to gravityMaxWells. The first is always a black hole. They are
+// kept clear of the middle of the screen, where the ship respawns, and of each
+// other.
+func (game *Game) placeWells() {
+	for _, well := range game.wells {
+		well.Destroy(true)
+	}
+	game.wells = game.wells[:0]
+	count := 1 + (game.wave-1)/2
+	if count > gravityMaxWells {
+		count = gravityMaxWells
+	}
+	for i := 0; i < count; i++ {
+		well := &Well{game: game, kind: wellBlackHole}
+		if i > 0 {
+			well.kind = wellKind(game.rng.Intn(len(wellClasses)))
+		}
+		for try := 0; try < wellPlaceTries; try++ {
+			well.x, well.y = game.randMax(game.screenWidth), game.randMax(game.screenHeight)
+			if game.wellPlaceFree(well.x, well.y) {
+				break
+			}
+		}
+		game.wells = append(game.wells, well)
+		game.entities.Spawn(well)
+	}
+}
+
+func (game *Game) wellPlaceFree(x, y float32) bool {
+	if dx, dy := x-game.screenWidth/2, y-game.screenHeight/2; dx*dx+dy*dy < wellSafeRadius*wellSafeRadius {
+		return false
+	}
+	for _, well := range game.wells {
+		dx, dy := game.offset(x, y, well.x, well.y)
+		if dx*dx+dy*dy < wellSpacing*wellSpacing {
+			return false
+		}
+	}
+	return true
+}
+
+// offset is the shortest way from one point to another on the wrapped screen.
+func (game *Game) offset(fromX, fromY, toX, toY float32) (float32, float32) {
+	return nearest(toX-fromX, game.screenWidth), nearest(toY-fromY, game.screenHeight)
+}
+
+// updateWells sets the acceleration of everything the wells pull on for this
+// step, which the player adds its thrust to, and destroys whatever has reached
+// an event horizon. Without any wells it just clears the acceleration.
+func (game *Game) updateWells() {
+	game.entities.Each(func(id EntityID, object GameObject) {
+		sprite := pulledSprite(object)
+		if sprite == nil {
+			return
+		}
+		sprite.ax, sprite.ay = 0, 0
+		minX, minY, maxX, maxY := sprite.body.GetBounds()
+		radius := (maxX - minX + maxY - minY) / 4
+		for _, well := range game.wells {
+			class := wellClasses[well.kind]
+			dx, dy := game.offset(sprite.x, sprite.y, well.x, well.y)
+			distance := sqrt(dx*dx + dy*dy)
+			if distance < class.horizon+radius {
+				object.Destroy(false)
+				return
+			}
+			pull := class.strength / (distance * distance * distance)
+			sprite.ax, sprite.ay = sprite.ax+dx*pull, sprite.ay+dy*pull
+		}
+	})
+}
+
+// pulledSprite returns the sprite of objects that wells pull on.
+func pulledSprite(object GameObject) *Sprite {
+	switch object := object.(type) {
+	case *Player:
+		return object.Sprite
+	case *Asteroid:
+		return object.Sprite
+	case *Bullet:
+		return object.Sprite
+	}
+	return nil
+}
+
+// orbit sets the sprite moving in a circle around the well pulling hardest on
+// it, clockwise or not at random.
+func (game *Game) orbit(sprite *Sprite) {
+	var strongest float32
+	for _, well := range game.wells {
+		class := wellClasses[well.kind]
+		dx, dy := game.offset(sprite.x, sprite.y, well.x, well.y)
+		distance := sqrt(dx*dx + dy*dy)
+		if pull := class.strength / (distance * distance); distance > 0 && pull > strongest {
+			strongest = pull
+			speed := sqrt(class.strength/distance) / distance
+			sprite.vx, sprite.vy = -dy*speed, dx*speed
+		}
+	}
+	if game.rng.Intn(2) == 0 {
+		sprite.vx, sprite.vy = -sprite.vx, -sprite.vy
+	}
+}
+
+// wellBonus multiplies the points for destroying something at x, y when it was
+// taken out close to a well.
+func (game *Game) wellBonus(x, y float32) int {
+	for _, well := range game.wells {
+		reach := wellClasses[well.kind].horizon * wellBonusRange
+		if dx, dy := game.offset(x, y, well.x, well.y); dx*dx+dy*dy < reach*reach {
+			return wellBonusMultiplier
+		}
+	}
+	return 1
+}
@@ -1,33 +1,197 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 
 	"github.com/tanema/amore-examples/asteroids/game"
+	"github.com/tanema/amore-examples/highscore"
 
 	"github.com/tanema/amore"
+	"github.com/tanema/amore/audio"
 	"github.com/tanema/amore/gfx"
 	"github.com/tanema/amore/keyboard"
 	"github.com/tanema/amore/timer"
 )
 
+var (
+	record    = flag.String("record", "", "record each round's input to this file")
+	replay    = flag.String("replay", "", "play back a round recorded with -record")
+	ships     = flag.String("ships", "assets/ships.json", "the ship classes to pick from, reloaded when the file changes")
+	tuning    = flag.String("tuning", "assets/tuning.json", "balance numbers, reloaded when the file changes")
+	soak      = flag.Duration("soak", 0, "let the autopilot play headless for this long and report how it went")
+	host      = flag.String("host", "", "host a two player round on this address, like :7777")
+	join      = flag.String("join", "", "join the two player round hosted on this address")
+	versus    = flag.Bool("versus", false, "when hosting, let the ships shoot each other instead of working together")
+	loopback  = flag.Duration("loopback", 0, "play a two player round against itself over loopback for this long and report how it went")
+	asteroids *game.Game
+)
+
 func main() {
+	flag.Parse()
+	if *soak > 0 {
+		report := game.Soak(800, 600, *soak)
+		fmt.Println(report)
+		if len(report.Panics) > 0 || len(report.Leaks) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if *loopback > 0 {
+		report := game.Loopback(800, 600, *loopback, *versus)
+		fmt.Println(report)
+		if len(report.Problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
 	amore.OnLoad = load
 	amore.Start(update, draw)
 }
 
 func load() {
-	game.New()
+	asteroids = game.New(gfx.GetWidth(), gfx.GetHeight(), keyboardControls{}, loadAudio(), gfxRenderer{})
+	asteroids.SetHighScores(game.ModeClassic, highscore.Load("asteroids", false))
+	asteroids.SetHighScores(game.ModeGravity, highscore.Load("asteroids-gravity", false))
+	asteroids.WatchTuning(*tuning)
+	asteroids.WatchShips(*ships)
+	keyboard.OnKeyUp = keyup
+
+	if *replay != "" {
+		r, err := game.LoadReplay(*replay)
+		if err != nil {
+			panic(err)
+		}
+		asteroids.Play(r)
+	} else if *record != "" {
+		asteroids.Record(*record)
+	}
+	if *host != "" {
+		if err := asteroids.Host(*host, *versus); err != nil {
+			fmt.Println("could not host:", err)
+		}
+	} else if *join != "" {
+		if err := asteroids.Join(*join); err != nil {
+			fmt.Println("could not join:", err)
+		}
+	}
+}
+
+func keyup(key keyboard.Key) {
+	if initials := asteroids.Initials(); initials != nil {
+		switch {
+		case key >= keyboard.KeyA && key <= keyboard.KeyZ:
+			initials.Type('A' + rune(key-keyboard.KeyA))
+		case key == keyboard.KeyBackspace:
+			initials.Erase()
+		case key == keyboard.KeyReturn:
+			asteroids.SubmitInitials()
+		}
+		return
+	}
+
+	if key == keyboard.KeyTab {
+		asteroids.ToggleDebug()
+	}
+	if key == keyboard.KeyReturn {
+		asteroids.Reset()
+	}
 }
 
 func update(deltaTime float32) {
 	if keyboard.IsDown(keyboard.KeyEscape) {
+		asteroids.Stop()
+		asteroids.Reset()
 		amore.Quit()
 	}
-	game.Update(deltaTime)
+	asteroids.Update(deltaTime)
 }
 
 func draw() {
-	game.Draw()
+	asteroids.Draw()
 	gfx.Print(fmt.Sprintf("fps: %v", timer.GetFPS()))
 }
+
+type keyboardControls struct{}
+
+func (keyboardControls) Read() game.Input {
+	var input game.Input
+	if keyboard.IsDown(keyboard.KeyLeft) {
+		input |= game.InputLeft
+	}
+	if keyboard.IsDown(keyboard.KeyRight) {
+		input |= game.InputRight
+	}
+	if keyboard.IsDown(keyboard.KeyUp) {
+		input |= game.InputThrust
+	}
+	if keyboard.IsDown(keyboard.KeySpace) {
+		input |= game.InputFire
+	}
+	if keyboard.IsDown(keyboard.KeyDown) {
+		input |= game.InputShield
+	}
+	if keyboard.IsDown(keyboard.KeyH) {
+		input |= game.InputHyperspace
+	}
+	return input
+}
+
+// audioDevice has a source loaded for every voice of every sound.
+type audioDevice map[game.Sound][]*audio.Source
+
+var soundFiles = map[game.Sound]string{
+	game.SoundShot:      "audio/lazer.wav",
+	game.SoundExplosion: "audio/bomb.wav",
+	game.SoundThrust:    "audio/thrust.wav",
+	game.SoundBeatLow:   "audio/beat1.wav",
+	game.SoundBeatHigh:  "audio/beat2.wav",
+}
+
+func loadAudio() audioDevice {
+	device := audioDevice{}
+	for sound, path := range soundFiles {
+		for i := 0; i < game.Voices(sound); i++ {
+			source, err := audio.NewSource(path, true)
+			if err != nil {
+				fmt.Println("could not load sound:", err)
+				break
+			}
+			device[sound] = append(device[sound], source)
+		}
+	}
+	return device
+}
+
+func (device audioDevice) Play(sound game.Sound, voice int, volume, pitch float32) {
+	if voices := device[sound]; voice < len(voices) {
+		source := voices[voice]
+		source.Stop()
+		source.SetVolume(volume)
+		source.SetPitch(pitch)
+		source.Play()
+	}
+}
+
+func (device audioDevice) Loop(sound game.Sound, playing bool) {
+	for _, source := range device[sound] {
+		if playing {
+			source.SetLooping(true)
+			source.Play()
+		} else {
+			source.Stop()
+		}
+	}
+}
+
+type gfxRenderer struct{}
+
+func (gfxRenderer) SetColor(r, g, b, a float32)      { gfx.SetColor(r, g, b, a) }
+func (gfxRenderer) Line(x0, y0, x1, y1 float32)      { gfx.Line(x0, y0, x1, y1) }
+func (gfxRenderer) Rect(x, y, width, height float32) { gfx.Rect(gfx.LINE, x, y, width, height) }
+func (gfxRenderer) PolyLine(points []float32)        { gfx.PolyLine(points) }
+func (gfxRenderer) Circle(x, y, radius float32)      { gfx.Circle(gfx.LINE, x, y, radius) }
+func (gfxRenderer) Print(text string, x, y, scaleX, scaleY float32) {
+	gfx.Print(text, x, y, 0, scaleX, scaleY)
+}
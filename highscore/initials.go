@@ -0,0 +1,44 @@
+package highscore
+
+import (
+	"strings"
+)
+
+// InitialsLength is how many letters can be entered for a high score.
+const InitialsLength = 3
+
+// Initials is the name being typed in for a new high score.
+type Initials struct {
+	letters []rune
+}
+
+// Type adds a letter or digit, ignoring anything else or anything past
+// InitialsLength.
+func (initials *Initials) Type(r rune) {
+	if len(initials.letters) >= InitialsLength {
+		return
+	}
+	if r >= 'a' && r <= 'z' {
+		r -= 'a' - 'A'
+	}
+	if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+		initials.letters = append(initials.letters, r)
+	}
+}
+
+// Erase removes the last letter typed.
+func (initials *Initials) Erase() {
+	if len(initials.letters) > 0 {
+		initials.letters = initials.letters[:len(initials.letters)-1]
+	}
+}
+
+// Value is the initials typed so far.
+func (initials *Initials) Value() string {
+	return string(initials.letters)
+}
+
+// String is the initials padded with underscores for display.
+func (initials *Initials) String() string {
+	return initials.Value() + strings.Repeat("_", InitialsLength-len(initials.letters))
+}
@@ -0,0 +1,126 @@
+// Package highscore keeps a small table of the best scores for a game, saved as
+// JSON in the user's config directory, and the initials entry that goes with it.
+package highscore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Size is the number of entries a table keeps.
+const Size = 10
+
+type Entry struct {
+	Initials string `json:"initials"`
+	Score    int    `json:"score"`
+}
+
+type Table struct {
+	Entries       []Entry
+	path          string
+	lowerIsBetter bool
+}
+
+// Load reads the table for the named game from the user's config directory. Set
+// lowerIsBetter for scores like lap times where the smallest score wins.
+func Load(name string, lowerIsBetter bool) *Table {
+	path := ""
+	if dir, err := os.UserConfigDir(); err == nil {
+		path = filepath.Join(dir, "amore-examples", name+".json")
+	}
+	return LoadFile(path, lowerIsBetter)
+}
+
+// LoadFile reads a table from path. A missing or unreadable file gives an empty
+// table and a corrupt one is moved aside to path.corrupt so it isn't overwritten
+// without a trace. An empty path gives a table that is never saved.
+func LoadFile(path string, lowerIsBetter bool) *Table {
+	table := &Table{
+		Entries:       []Entry{},
+		path:          path,
+		lowerIsBetter: lowerIsBetter,
+	}
+	if path == "" {
+		return table
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return table
+	}
+	entries := []Entry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		os.Rename(path, path+".corrupt")
+		return table
+	}
+	for _, entry := range entries {
+		table.Add(entry.Initials, entry.Score)
+	}
+	return table
+}
+
+func (table *Table) better(score, other int) bool {
+	if table.lowerIsBetter {
+		return score < other
+	}
+	return score > other
+}
+
+// Qualifies reports if the score would make it into the table.
+func (table *Table) Qualifies(score int) bool {
+	return len(table.Entries) < Size || table.better(score, table.Entries[len(table.Entries)-1].Score)
+}
+
+// Add puts the score in its place in the table and returns its rank, starting at
+// zero, or -1 if it didn't make it. Ties go below the scores already there.
+func (table *Table) Add(initials string, score int) int {
+	if !table.Qualifies(score) {
+		return -1
+	}
+	rank := len(table.Entries)
+	for i, entry := range table.Entries {
+		if table.better(score, entry.Score) {
+			rank = i
+			break
+		}
+	}
+	entry := Entry{Initials: cleanInitials(initials), Score: score}
+	table.Entries = append(table.Entries, Entry{})
+	copy(table.Entries[rank+1:], table.Entries[rank:])
+	table.Entries[rank] = entry
+	if len(table.Entries) > Size {
+		table.Entries = table.Entries[:Size]
+	}
+	return rank
+}
+
+// Save writes the table to its file, writing to a temporary file first so that a
+// crash part way through can't leave a half written table behind.
+func (table *Table) Save() error {
+	if table.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(table.Entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(table.path), 0755); err != nil {
+		return err
+	}
+	tmp := table.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, table.path)
+}
+
+func cleanInitials(initials string) string {
+	initials = strings.ToUpper(strings.TrimSpace(initials))
+	if len(initials) > InitialsLength {
+		initials = initials[:InitialsLength]
+	}
+	return initials
+}
@@ -0,0 +1,98 @@
+package highscore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func scores(table *Table) []int {
+	result := []int{}
+	for _, entry := range table.Entries {
+		result = append(result, entry.Score)
+	}
+	return result
+}
+
+func TestLoadMissing(t *testing.T) {
+	table := LoadFile(filepath.Join(t.TempDir(), "missing.json"), false)
+	if len(table.Entries) != 0 {
+		t.Errorf("a missing file gave %v", table.Entries)
+	}
+	if table := LoadFile("", false); len(table.Entries) != 0 || table.Save() != nil {
+		t.Error("a table without a path should be empty and never saved")
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json")
+	if err := ioutil.WriteFile(path, []byte(`[{"initials": "ABC", "sco`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	table := LoadFile(path, false)
+	if len(table.Entries) != 0 {
+		t.Errorf("a corrupt file gave %v", table.Entries)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the corrupt file was left in place: %v", err)
+	}
+	if data, err := ioutil.ReadFile(path + ".corrupt"); err != nil || string(data) != `[{"initials": "ABC", "sco` {
+		t.Errorf("the corrupt file wasn't moved aside: %q, %v", data, err)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	table := LoadFile("", false)
+	for i, score := range []int{50, 80, 20} {
+		table.Add("abcd", score)
+		if table.Entries[0].Initials != "ABC" {
+			t.Fatalf("entry %v has initials %q", i, table.Entries[0].Initials)
+		}
+	}
+	if rank := table.Add("tie", 50); rank != 2 {
+		t.Errorf("a tie ranked %v, ahead of the score already there", rank)
+	}
+	if got := scores(table); !reflect.DeepEqual(got, []int{80, 50, 50, 20}) {
+		t.Errorf("scores are %v", got)
+	}
+
+	for score := 100; len(table.Entries) < Size; score++ {
+		table.Add("", score)
+	}
+	if table.Qualifies(20) || table.Add("low", 20) != -1 {
+		t.Error("a score tied with the last in a full table made it in")
+	}
+	if rank := table.Add("top", 1000); rank != 0 || len(table.Entries) != Size || table.Entries[Size-1].Score != 50 {
+		t.Errorf("rank %v, %v entries, last %v", rank, len(table.Entries), table.Entries[Size-1].Score)
+	}
+
+	laps := LoadFile("", true)
+	for _, lap := range []int{300, 100, 200} {
+		laps.Add("", lap)
+	}
+	if got := scores(laps); !reflect.DeepEqual(got, []int{100, 200, 300}) {
+		t.Errorf("lap times are %v", got)
+	}
+	if !laps.Qualifies(400) {
+		t.Error("a table with room left should take any time")
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores", "laps.json")
+	table := LoadFile(path, true)
+	table.Add("abc", 300)
+	table.Add("xyz", 100)
+	if err := table.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("the temporary file was left behind: %v", err)
+	}
+	loaded := LoadFile(path, true)
+	if !reflect.DeepEqual(loaded.Entries, table.Entries) {
+		t.Errorf("saved %v and loaded %v", table.Entries, loaded.Entries)
+	}
+}
@@ -0,0 +1,337 @@
+package game
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+const (
+	guardianCount        = 10
+	generatorAttempts    = 5
+	indestructibleChance = 0.75
+	// how far, in pixels, guardians are kept from where the player starts
+	guardianClearance = 500
+	// the rows between tiers of platforms
+	tierHeight  = 4
+	seedLetters = "abcdefghjkmnpqrstuvwxyz23456789"
+	seedLength  = 6
+	// the fewest cells across and down a generated level has, room for the
+	// walls and the player's start
+	minLevelCells = 6
+)
+
+// RandomSeed makes up a seed for GenerateLevel.
+func RandomSeed() string {
+	seed := make([]byte, seedLength)
+	for i := range seed {
+		seed[i] = seedLetters[rand.Intn(len(seedLetters))]
+	}
+	return string(seed)
+}
+
+// GenerateLevel makes up a level of the given size from a seed. The same seed
+// and size always make the same level. Every guardian can be reached from the
+// player's start by walking, jumping and falling, without counting on being
+// able to fly. A layout that fails this is thrown away for the next one the seed
+// makes, and if none of them pass the last one is patched by moving the
+// guardians that are out of reach. Levels are made at least minLevelCells
+// across and down.
+func GenerateLevel(seed string, width, height float32) *Level {
+	gen := newGenerator(seed, width, height)
+	gen.generate()
+	return gen.level(seed)
+}
+
+func newGenerator(seed string, width, height float32) *generator {
+	hash := fnv.New64a()
+	hash.Write([]byte(seed))
+	return &generator{
+		rng:  rand.New(rand.NewSource(int64(hash.Sum64()))),
+		cols: maxInt(minLevelCells, int(width/cellSize)),
+		rows: maxInt(minLevelCells, int(height/cellSize)),
+	}
+}
+
+// generate lays levels out until every guardian can be reached, or patches the
+// last one.
+func (gen *generator) generate() {
+	for attempt := 1; ; attempt++ {
+		gen.layout()
+		gen.placeGuardians()
+		if len(gen.unreachable()) == 0 {
+			break
+		}
+		if attempt == generatorAttempts {
+			gen.patch()
+			return
+		}
+	}
+}
+
+// generator lays a level out on a grid of cells.
+type generator struct {
+	rng        *rand.Rand
+	cols, rows int
+	grid       *grid
+	blocks     []levelBlock
+	start      spot
+	// where each guardian stands, by the bottom left of the two cells it is
+	// wide
+	guardians []spot
+}
+
+// the cells a guardian covers
+var guardianCols, guardianRows = int(ceil(guardianWidth / cellSize)), int(ceil(guardianHeight / cellSize))
+
+// layout builds the walls, ceiling and floor and then scatters platforms and
+// clusters of blocks over the level, leaving room for the player to start in the
+// bottom left.
+func (gen *generator) layout() {
+	gen.grid = newGrid(gen.cols, gen.rows)
+	gen.blocks = nil
+	gen.guardians = nil
+
+	// walls & ceiling
+	gen.block(0, 0, gen.cols, 1, true)
+	gen.block(0, 1, 1, gen.rows-2, true)
+	gen.block(gen.cols-1, 1, 1, gen.rows-2, true)
+	// tiled floor
+	for col := 0; col < gen.cols; col += 3 {
+		gen.block(col, gen.rows-1, minInt(3, gen.cols-col), 1, true)
+	}
+
+	// platforms sit on tiers a short jump apart so that most of them can be
+	// climbed to from the one below
+	area := gen.cols * gen.rows
+	tiers := (gen.rows - 5) / tierHeight
+	if tiers < 1 || gen.cols-4 < 3 {
+		// no room for any
+		area = 0
+	}
+	for i := 0; i < area/80; i++ {
+		width := gen.between(3, minInt(10, gen.cols-4))
+		thickness := 1
+		if gen.rng.Float32() < 0.3 {
+			thickness = 2
+		}
+		col := gen.between(2, gen.cols-2-width)
+		row := gen.rows - 1 - tierHeight*gen.between(1, tiers)
+		gen.run(col, row, width, thickness, gen.rng.Float32() < indestructibleChance)
+	}
+	area = gen.cols * gen.rows
+	if gen.cols-4 < 2 || gen.rows-6 < 2 {
+		area = 0
+	}
+	for i := 0; i < area/400; i++ {
+		width, height := gen.between(2, minInt(6, gen.cols-4)), gen.between(2, minInt(5, gen.rows-6))
+		col := gen.between(2, gen.cols-2-width)
+		row := gen.between(4, gen.rows-2-height)
+		indestructible := gen.rng.Float32() < indestructibleChance
+		for c := col; c < col+width; c++ {
+			for r := row; r < row+height; r++ {
+				if gen.rng.Intn(2) == 0 {
+					gen.block(c, r, 1, 1, indestructible)
+				}
+			}
+		}
+	}
+
+	// room to stand up and jump from at the start, inside the walls
+	gen.start = spot{col: 2, row: gen.rows - 2}
+	top := maxInt(1, gen.rows-6)
+	gen.clear(1, top, minInt(4, gen.cols-2), gen.rows-1-top)
+}
+
+// between returns a whole number from min to max, both included.
+func (gen *generator) between(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + gen.rng.Intn(max-min+1)
+}
+
+// block adds a block covering the cells.
+func (gen *generator) block(col, row, cols, rows int, indestructible bool) {
+	gen.grid.fill(col, row, cols, rows, true)
+	gen.blocks = append(gen.blocks, levelBlock{
+		l:              float32(col) * cellSize,
+		t:              float32(row) * cellSize,
+		w:              float32(cols) * cellSize,
+		h:              float32(rows) * cellSize,
+		indestructible: indestructible,
+	})
+}
+
+// run lays a platform out of blocks from one to three cells long, so that an
+// explosion only takes out part of it.
+func (gen *generator) run(col, row, cols, rows int, indestructible bool) {
+	for end := col + cols; col < end; {
+		length := minInt(gen.between(1, 3), end-col)
+		gen.block(col, row, length, rows, indestructible)
+		col += length
+	}
+}
+
+// clear removes every block that overlaps the cells.
+func (gen *generator) clear(col, row, cols, rows int) {
+	l, t := float32(col)*cellSize, float32(row)*cellSize
+	w, h := float32(cols)*cellSize, float32(rows)*cellSize
+	kept := gen.blocks[:0]
+	gen.grid = newGrid(gen.cols, gen.rows)
+	for _, block := range gen.blocks {
+		if block.l < l+w && l < block.l+block.w && block.t < t+h && t < block.t+block.h {
+			continue
+		}
+		gen.grid.fill(int(block.l/cellSize), int(block.t/cellSize), int(block.w/cellSize), int(block.h/cellSize), true)
+		kept = append(kept, block)
+	}
+	gen.blocks = kept
+}
+
+// fits reports if a guardian can stand at the spot: out of every block, on solid
+// ground and not right next to the player's start.
+func (gen *generator) fits(at spot) bool {
+	if !gen.grid.free(at.col, at.row-guardianRows+1, guardianCols, guardianRows) {
+		return false
+	}
+	for col := at.col; col < at.col+guardianCols; col++ {
+		if !gen.grid.at(col, at.row+1) {
+			return false
+		}
+	}
+	dx, dy := float32(at.col-gen.start.col)*cellSize, float32(at.row-gen.start.row)*cellSize
+	return dx*dx+dy*dy > guardianClearance*guardianClearance
+}
+
+// placeGuardians stands guardians on free ground around the level.
+func (gen *generator) placeGuardians() {
+	candidates := []spot{}
+	for row := 1; row < gen.rows-1; row++ {
+		for col := 1; col < gen.cols-1; col++ {
+			if at := (spot{col: col, row: row}); gen.fits(at) {
+				candidates = append(candidates, at)
+			}
+		}
+	}
+	for len(gen.guardians) < guardianCount && len(candidates) > 0 {
+		i := gen.rng.Intn(len(candidates))
+		at := candidates[i]
+		candidates = append(candidates[:i], candidates[i+1:]...)
+		if gen.fits(at) {
+			gen.placeGuardian(at)
+		}
+	}
+}
+
+// placeGuardian marks the guardian's cells solid, as the player can't walk
+// through it.
+func (gen *generator) placeGuardian(at spot) {
+	gen.grid.fill(at.col, at.row-guardianRows+1, guardianCols, guardianRows, true)
+	gen.guardians = append(gen.guardians, at)
+}
+
+func (gen *generator) removeGuardian(i int) {
+	at := gen.guardians[i]
+	gen.grid.fill(at.col, at.row-guardianRows+1, guardianCols, guardianRows, false)
+	gen.guardians = append(gen.guardians[:i], gen.guardians[i+1:]...)
+}
+
+// unreachable returns the index of every guardian the player can't get to.
+func (gen *generator) unreachable() []int {
+	reached := gen.grid.reach(gen.start)
+	missed := []int{}
+	for i, at := range gen.guardians {
+		if !gen.touches(reached, at) {
+			missed = append(missed, i)
+		}
+	}
+	return missed
+}
+
+// touches reports if the player can stand right beside the guardian or on top
+// of it.
+func (gen *generator) touches(reached []bool, at spot) bool {
+	spots := []spot{{col: at.col - 1, row: at.row}, {col: at.col + guardianCols, row: at.row}}
+	for col := at.col; col < at.col+guardianCols; col++ {
+		spots = append(spots, spot{col: col, row: at.row - guardianRows})
+	}
+	for _, next := range spots {
+		if next.col >= 0 && next.row >= 0 && next.col < gen.cols && next.row < gen.rows && reached[next.row*gen.cols+next.col] {
+			return true
+		}
+	}
+	return false
+}
+
+// patch moves every guardian that is out of reach to somewhere the player can
+// get to, checking that it doesn't cut any other guardian off. Guardians with
+// nowhere to go are left out.
+func (gen *generator) patch() {
+	missed := gen.unreachable()
+	for i := len(missed) - 1; i >= 0; i-- {
+		gen.removeGuardian(missed[i])
+	}
+	for range missed {
+		reached := gen.grid.reach(gen.start)
+		candidates := []spot{}
+		for row := 1; row < gen.rows-1; row++ {
+			for col := 1; col < gen.cols-1; col++ {
+				if at := (spot{col: col, row: row}); gen.fits(at) && gen.touches(reached, at) {
+					candidates = append(candidates, at)
+				}
+			}
+		}
+		for len(candidates) > 0 {
+			i := gen.rng.Intn(len(candidates))
+			at := candidates[i]
+			candidates = append(candidates[:i], candidates[i+1:]...)
+			gen.placeGuardian(at)
+			if len(gen.unreachable()) == 0 {
+				break
+			}
+			gen.removeGuardian(len(gen.guardians) - 1)
+		}
+	}
+}
+
+// level turns the layout into a level the map can build.
+func (gen *generator) level(seed string) *Level {
+	level := &Level{
+		seed:   seed,
+		width:  float32(gen.cols) * cellSize,
+		height: float32(gen.rows) * cellSize,
+		blocks: gen.blocks,
+	}
+	level.objects = append(level.objects, levelObject{
+		kind: "player",
+		l:    float32(gen.start.col) * cellSize,
+		t:    float32(gen.start.row+1)*cellSize - playerHeight,
+		w:    playerWidth,
+		h:    playerHeight,
+	})
+	for _, at := range gen.guardians {
+		level.objects = append(level.objects, levelObject{
+			kind: "guardian",
+			// centered on the cells it stands in
+			l: float32(at.col)*cellSize + (float32(guardianCols)*cellSize-guardianWidth)/2,
+			t: float32(at.row+1)*cellSize - guardianHeight,
+			w: guardianWidth,
+			h: guardianHeight,
+		})
+	}
+	return level
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
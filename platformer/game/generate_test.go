@@ -0,0 +1,70 @@
+package game
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func overlaps(l, t, w, h float32, block levelBlock) bool {
+	return block.l < l+w && l < block.l+block.w && block.t < t+h && t < block.t+block.h
+}
+
+func TestGenerateLevel(t *testing.T) {
+	sizes := []struct {
+		width, height float32
+		seeds         int
+	}{
+		{1920, 960, 8},
+		{640, 480, 40},
+		// too short for any tiers of platforms
+		{640, 256, 40},
+		// too narrow for platforms or clusters
+		{192, 480, 40},
+		{192, 192, 40},
+		// smaller than a level can be
+		{64, 64, 10},
+		{0, 0, 10},
+	}
+	for _, size := range sizes {
+		for i := 0; i < size.seeds; i++ {
+			seed := fmt.Sprint("seed", i)
+			name := fmt.Sprintf("%vx%v/%v", size.width, size.height, seed)
+			gen := newGenerator(seed, size.width, size.height)
+			gen.generate()
+			if missed := gen.unreachable(); len(missed) > 0 {
+				t.Errorf("%v: guardians %v can't be reached", name, missed)
+			}
+			level := gen.level(seed)
+			if again := GenerateLevel(seed, size.width, size.height); !reflect.DeepEqual(level, again) {
+				t.Errorf("%v: the same seed made a different level", name)
+			}
+			if level.width < minLevelCells*cellSize || level.height < minLevelCells*cellSize {
+				t.Errorf("%v: level is %vx%v", name, level.width, level.height)
+			}
+			for _, block := range level.blocks {
+				if block.l < 0 || block.t < 0 || block.l+block.w > level.width || block.t+block.h > level.height {
+					t.Errorf("%v: block %+v is outside the level", name, block)
+				}
+			}
+			players := 0
+			for _, object := range level.objects {
+				players += map[string]int{"player": 1}[object.kind]
+				for _, block := range level.blocks {
+					if overlaps(object.l, object.t, object.w, object.h, block) {
+						t.Errorf("%v: %v at %v,%v overlaps block %+v", name, object.kind, object.l, object.t, block)
+					}
+				}
+			}
+			if players != 1 {
+				t.Errorf("%v: %v players", name, players)
+			}
+		}
+	}
+}
+
+func TestGenerateLevelSeeds(t *testing.T) {
+	if reflect.DeepEqual(GenerateLevel("abc", 1920, 960), GenerateLevel("xyz", 1920, 960)) {
+		t.Error("different seeds made the same level")
+	}
+}
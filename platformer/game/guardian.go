@@ -4,6 +4,11 @@ import (
 	"github.com/tanema/amore/gfx"
 )
 
+const (
+	guardianWidth  float32 = 42
+	guardianHeight float32 = 110
+)
+
 type Guardian struct {
 	*Entity
 	gameMap                    *Map
@@ -28,7 +33,7 @@ func newGuardian(gameMap *Map, l, t float32) *Guardian {
 		targetCoolDown:             2,
 		timeSinceLastTargetAquired: 2,
 	}
-	guardian.Entity = newEntity(gameMap, guardian, "guardian", l, t, 42, 110)
+	guardian.Entity = newEntity(gameMap, guardian, "guardian", l, t, guardianWidth, guardianHeight)
 
 	l, t, w, h := guardian.Extents()
 	others := gameMap.world.QueryRect(l, t, w, h, "block")
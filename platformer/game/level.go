@@ -0,0 +1,213 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// properties are the custom properties set on a tile, layer or object in Tiled,
+// kept as they are written in a TMX file.
+type properties map[string]string
+
+// set checks the value against the type Tiled saved it with so that a typo in a
+// number shows up when the level is loaded rather than as a default later.
+func (props properties) set(name, kind, value string) error {
+	var err error
+	switch kind {
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	case "int":
+		_, err = strconv.Atoi(value)
+	case "float":
+		_, err = strconv.ParseFloat(value, 32)
+	}
+	if err != nil {
+		return fmt.Errorf("property %q: %q is not a valid %v", name, value, kind)
+	}
+	props[name] = value
+	return nil
+}
+
+func (props properties) float(name string, fallback float32) float32 {
+	if value, err := strconv.ParseFloat(props[name], 32); err == nil {
+		return float32(value)
+	}
+	return fallback
+}
+
+func (props properties) bool(name string, fallback bool) bool {
+	if value, err := strconv.ParseBool(props[name]); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// over returns the properties with others set on top of them.
+func (props properties) over(others properties) properties {
+	merged := properties{}
+	for name, value := range props {
+		merged[name] = value
+	}
+	for name, value := range others {
+		merged[name] = value
+	}
+	return merged
+}
+
+// Level is the blocks and objects a map is built from, either made up from a
+// seed by GenerateLevel or loaded from Tiled by LoadLevel. In a Tiled map every
+// tile on a tile layer is a block, which is indestructible if the tile or else
+// its layer has the indestructible property set. Each object on an object layer
+// spawns the entity named by its type, with its own custom properties on top of
+// those of its tile and layer. There has to be exactly one player.
+type Level struct {
+	seed          string
+	width, height float32
+	blocks        []levelBlock
+	objects       []levelObject
+}
+
+// Seed is the seed a generated level was made from, empty for a level loaded
+// from Tiled.
+func (level *Level) Seed() string {
+	return level.seed
+}
+
+type levelBlock struct {
+	l, t, w, h     float32
+	indestructible bool
+}
+
+type levelObject struct {
+	name, kind string
+	l, t, w, h float32
+	properties properties
+}
+
+// spawners create the entities placed on a level's object layers by their type
+// in Tiled. A new kind of entity only needs an entry here to be placed in
+// levels.
+var spawners = map[string]func(m *Map, object levelObject){
+	"player": func(m *Map, object levelObject) {
+		m.Player = newPlayer(m, object.l, object.t)
+	},
+	"guardian": func(m *Map, object levelObject) {
+		guardian := newGuardian(m, object.l, object.t)
+		guardian.activeRadius = object.properties.float("activeRadius", guardian.activeRadius)
+		guardian.fireCoolDown = object.properties.float("fireCoolDown", guardian.fireCoolDown)
+		guardian.aimDuration = object.properties.float("aimDuration", guardian.aimDuration)
+	},
+	"block": func(m *Map, object levelObject) {
+		newBlock(m, object.l, object.t, object.w, object.h, object.properties.bool("indestructible", false))
+	},
+}
+
+// LoadLevel reads a level from a map saved by Tiled, either as .tmx or as JSON.
+// Only orthogonal, fixed size maps can be played.
+func LoadLevel(path string) (*Level, error) {
+	tiled, err := loadTiled(path)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	level, err := newLevel(tiled)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return level, nil
+}
+
+func newLevel(tiled *tiledMap) (*Level, error) {
+	switch {
+	case tiled.orientation != "orthogonal":
+		return nil, fmt.Errorf("%v maps are not supported, only orthogonal", tiled.orientation)
+	case tiled.infinite:
+		return nil, fmt.Errorf("infinite maps are not supported")
+	case tiled.width <= 0 || tiled.height <= 0 || tiled.tileWidth <= 0 || tiled.tileHeight <= 0:
+		return nil, fmt.Errorf("the map and its tiles need a size")
+	}
+	level := &Level{
+		width:  float32(tiled.width * tiled.tileWidth),
+		height: float32(tiled.height * tiled.tileHeight),
+	}
+	if err := level.addLayers(tiled, tiled.layers, 0, 0, properties{}); err != nil {
+		return nil, err
+	}
+
+	players := 0
+	for _, object := range level.objects {
+		if object.kind == "player" {
+			players++
+		}
+	}
+	if players != 1 {
+		return nil, fmt.Errorf("needs one player start, found %v", players)
+	}
+	return level, nil
+}
+
+// addLayers adds the blocks and objects of the layers, offset by and inheriting
+// the properties of any groups they are in.
+func (level *Level) addLayers(tiled *tiledMap, layers []tiledLayer, offsetX, offsetY float32, inherited properties) error {
+	for _, layer := range layers {
+		dx, dy := offsetX+layer.offsetX, offsetY+layer.offsetY
+		props := inherited.over(layer.properties)
+		switch layer.kind {
+		case tiledTileLayer:
+			if len(layer.gids) != layer.width*layer.height {
+				return fmt.Errorf("layer %q: has %v tiles but is %vx%v", layer.name, len(layer.gids), layer.width, layer.height)
+			}
+			w, h := float32(tiled.tileWidth), float32(tiled.tileHeight)
+			for i, gid := range layer.gids {
+				if gid &^= tiledFlipFlags; gid == 0 {
+					continue
+				}
+				level.blocks = append(level.blocks, levelBlock{
+					l:              dx + float32(i%layer.width)*w,
+					t:              dy + float32(i/layer.width)*h,
+					w:              w,
+					h:              h,
+					indestructible: props.over(tiled.tile(gid)).bool("indestructible", false),
+				})
+			}
+		case tiledObjectGroup:
+			for _, tiledObject := range layer.objects {
+				object := levelObject{
+					name:       tiledObject.name,
+					kind:       tiledObject.kind,
+					l:          dx + tiledObject.x,
+					t:          dy + tiledObject.y,
+					w:          tiledObject.width,
+					h:          tiledObject.height,
+					properties: props,
+				}
+				// tile objects hang from their bottom left corner and have
+				// the properties of their tile
+				if gid := tiledObject.gid &^ tiledFlipFlags; gid != 0 {
+					object.t -= object.h
+					object.properties = object.properties.over(tiled.tile(gid))
+				}
+				object.properties = object.properties.over(tiledObject.properties)
+				if _, ok := spawners[object.kind]; !ok {
+					return fmt.Errorf("layer %q: object %q has unknown type %q", layer.name, object.name, object.kind)
+				}
+				level.objects = append(level.objects, object)
+			}
+		case tiledGroup:
+			if err := level.addLayers(tiled, layer.layers, dx, dy, props); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// build fills the map with the level's blocks and then spawns its objects in the
+// order they were placed.
+func (level *Level) build(m *Map) {
+	for _, block := range level.blocks {
+		newBlock(m, block.l, block.t, block.w, block.h, block.indestructible)
+	}
+	for _, object := range level.objects {
+		spawners[object.kind](m, object)
+	}
+}
@@ -14,59 +14,38 @@ type Map struct {
 	debug        bool
 	camera       *lense.Camera
 	world        *ump.World
+	level        *Level
 }
 
-func NewMap(width, height float32, camera *lense.Camera) *Map {
+// NewMap creates a map playing the level, made with GenerateLevel or loaded with
+// LoadLevel.
+func NewMap(level *Level, camera *lense.Camera) *Map {
 	gameMap := &Map{
-		width:        width,
-		height:       height,
 		updateRadius: 100,
 		camera:       camera,
 	}
-	gameMap.Reset()
+	gameMap.Play(level)
 	return gameMap
 }
 
+// Play switches the map to another level, which is rebuilt every time the map is
+// reset.
+func (m *Map) Play(level *Level) {
+	m.level = level
+	m.width, m.height = level.width, level.height
+	m.Reset()
+}
+
+// Reset rebuilds the level being played.
 func (m *Map) Reset() {
 	m.objects = map[uint32]gameObject{}
 	m.world = ump.NewWorld(64)
-	m.Player = newPlayer(m, 60, 60)
-
-	// walls & ceiling
-	newBlock(m, 0, 0, m.width, 32, true)
-	newBlock(m, 0, 32, 32, m.height-64, true)
-	newBlock(m, m.width-32, 32, 32, m.height-64, true)
-
-	// tiled floor
-	tilesOnFloor := float32(40)
-	for i := float32(0); i < tilesOnFloor; i++ {
-		newBlock(m, i*m.width/tilesOnFloor, m.height-32, m.width/tilesOnFloor, 32, true)
-	}
-
-	// groups of blocks
-	for i := 1; i < 60; i++ {
-		w := randRange(100, 400)
-		h := randRange(100, 400)
-		area := w * h
-		l := randRange(100, m.width-w-200)
-		t := randRange(100, m.height-h-100)
-		indestructible := randRange(0, 1) < 0.75
-
-		for j := 1; j < int(floor(area/7000)); j++ {
-			newBlock(m,
-				randRange(l, l+w),
-				randRange(t, t+h),
-				randRange(32, 100),
-				randRange(32, 100),
-				indestructible)
-		}
-	}
+	m.level.build(m)
+}
 
-	for i := 0; i < 10; i++ {
-		newGuardian(m,
-			randRange(100, m.width-200),
-			randRange(100, m.height-150))
-	}
+// Seed is the seed of the level being played, empty if it was made in Tiled.
+func (m *Map) Seed() string {
+	return m.level.Seed()
 }
 
 func (m *Map) ToggleDebug() {
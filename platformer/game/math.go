@@ -14,6 +14,10 @@ func floor(x float32) float32 {
 	return float32(math.Floor(float64(x)))
 }
 
+func ceil(x float32) float32 {
+	return float32(math.Ceil(float64(x)))
+}
+
 func clamp(x, minX, maxX float32) float32 {
 	if x < minX {
 		return minX
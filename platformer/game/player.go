@@ -21,13 +21,15 @@ const (
 	jumpVelocity float32 = 400 // the initial upwards velocity when jumping
 	beltWidth    float32 = 2
 	beltHeight   float32 = 8
+	playerWidth  float32 = 32
+	playerHeight float32 = 64
 )
 
 func newPlayer(gameMap *Map, l, t float32) *Player {
 	player := &Player{
 		health: 1,
 	}
-	player.Entity = newEntity(gameMap, player, "player", l, t, 32, 64)
+	player.Entity = newEntity(gameMap, player, "player", l, t, playerWidth, playerHeight)
 	player.body.SetResponses(map[string]string{
 		"guardian": "slide",
 		"block":    "slide",
@@ -42,29 +44,13 @@ func (player *Player) changeVelocityByKeys(dt float32) {
 		return
 	}
 
+	dir := float32(0)
 	if keyboard.IsDown(keyboard.KeyLeft) {
-		if player.vx > 0 {
-			player.vx -= dt * brakeAccel
-		} else {
-			player.vx -= dt * runAccel
-		}
+		dir = -1
 	} else if keyboard.IsDown(keyboard.KeyRight) {
-		if player.vx < 0 {
-			player.vx += dt * brakeAccel
-		} else {
-			player.vx += dt * runAccel
-		}
-	} else {
-		brake := dt * -brakeAccel
-		if player.vx < 0 {
-			brake = dt * brakeAccel
-		}
-		if abs(brake) > abs(player.vx) {
-			player.vx = 0
-		} else {
-			player.vx += brake
-		}
+		dir = 1
 	}
+	player.vx = runVelocity(player.vx, dir, dt)
 
 	if keyboard.IsDown(keyboard.KeyUp) && (player.canFly() || player.onGround) { // jump/fly
 		player.vy = -jumpVelocity
@@ -72,6 +58,29 @@ func (player *Player) changeVelocityByKeys(dt float32) {
 	}
 }
 
+// runVelocity is the horizontal velocity after running in dir, -1 for left and 1
+// for right, or braking to a stop if dir is 0. Turning around brakes first.
+func runVelocity(vx, dir, dt float32) float32 {
+	switch {
+	case dir < 0 && vx > 0:
+		return vx - dt*brakeAccel
+	case dir < 0:
+		return vx - dt*runAccel
+	case dir > 0 && vx < 0:
+		return vx + dt*brakeAccel
+	case dir > 0:
+		return vx + dt*runAccel
+	}
+	brake := dt * -brakeAccel
+	if vx < 0 {
+		brake = dt * brakeAccel
+	}
+	if abs(brake) > abs(vx) {
+		return 0
+	}
+	return vx + brake
+}
+
 func (player *Player) moveColliding(dt float32) {
 	player.onGround = false
 	l, t, cols := player.Entity.body.Move(player.l+player.vx*dt, player.t+player.vy*dt)
@@ -0,0 +1,177 @@
+package game
+
+const (
+	// the size of a cell of a generated level, every block covers whole cells
+	cellSize float32 = 32
+	// the time step of the simulated leaps, which are made a little lower than
+	// the player can really jump so the small differences from the real
+	// collisions never make a leap impossible
+	leapStep     float32 = 1.0 / 60
+	leapMargin   float32 = 0.95
+	maxLeapSteps         = 300
+)
+
+// the frames to hold left or right for when trying leaps from a spot, first
+// while jumping and then while just walking off an edge
+var (
+	jumpHolds = []int{0, 6, 12, 18, 24, 30, 36, 42, 48, 60, 72, 90}
+	fallHolds = []int{18, 24, 36, 48}
+)
+
+// grid is which cells of a generated level are solid, either a block or a
+// guardian. Anything outside of it is solid too.
+type grid struct {
+	cols, rows int
+	solid      []bool
+}
+
+func newGrid(cols, rows int) *grid {
+	return &grid{cols: cols, rows: rows, solid: make([]bool, cols*rows)}
+}
+
+func (g *grid) at(col, row int) bool {
+	if col < 0 || row < 0 || col >= g.cols || row >= g.rows {
+		return true
+	}
+	return g.solid[row*g.cols+col]
+}
+
+// fill sets the cells of the rectangle, in cells, to solid or not.
+func (g *grid) fill(col, row, cols, rows int, solid bool) {
+	for c := col; c < col+cols; c++ {
+		for r := row; r < row+rows; r++ {
+			if c >= 0 && r >= 0 && c < g.cols && r < g.rows {
+				g.solid[r*g.cols+c] = solid
+			}
+		}
+	}
+}
+
+// free reports if none of the cells of the rectangle are solid.
+func (g *grid) free(col, row, cols, rows int) bool {
+	for c := col; c < col+cols; c++ {
+		for r := row; r < row+rows; r++ {
+			if g.at(c, r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// spot is a cell the player can stand in, its feet in the cell at row and its
+// head in the one above.
+type spot struct {
+	col, row int
+}
+
+func (g *grid) standing(at spot) bool {
+	return g.free(at.col, at.row-1, 1, 2) && g.at(at.col, at.row+1)
+}
+
+// reach walks the level graph from start. Its nodes are the spots the player
+// can stand in and its edges are walking to the next spot over or a leap that
+// lands on another. It returns which spots were reached, by cell.
+func (g *grid) reach(start spot) []bool {
+	reached := make([]bool, g.cols*g.rows)
+	if !g.standing(start) {
+		return reached
+	}
+	reached[start.row*g.cols+start.col] = true
+	queue := []spot{start}
+	visit := func(at spot) {
+		if index := at.row*g.cols + at.col; !reached[index] {
+			reached[index] = true
+			queue = append(queue, at)
+		}
+	}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, dir := range []int{-1, 1} {
+			if next := (spot{col: from.col + dir, row: from.row}); g.standing(next) {
+				visit(next)
+			}
+			for _, hold := range jumpHolds {
+				if to, ok := g.leap(from, true, float32(dir), hold); ok {
+					visit(to)
+				}
+			}
+			for _, hold := range fallHolds {
+				if to, ok := g.leap(from, false, float32(dir), hold); ok {
+					visit(to)
+				}
+			}
+		}
+	}
+	return reached
+}
+
+// leap moves a stand-in for the player from a spot the way the player moves:
+// jumping or not, holding dir for the given number of steps and then letting go
+// until it comes to a stop on the ground. It reports the spot it stopped in.
+func (g *grid) leap(from spot, jump bool, dir float32, hold int) (spot, bool) {
+	l, t := float32(from.col)*cellSize, float32(from.row+1)*cellSize-playerHeight
+	var vx, vy float32
+	if jump {
+		vy = -jumpVelocity * leapMargin
+	}
+	for step := 0; step < maxLeapSteps; step++ {
+		if step < hold {
+			vx = runVelocity(vx, dir, leapStep)
+		} else {
+			vx = runVelocity(vx, 0, leapStep)
+		}
+		vy += gravityAccel * leapStep
+
+		if next := l + vx*leapStep; g.overlaps(next, t) {
+			if vx > 0 {
+				l = floor((next+playerWidth)/cellSize)*cellSize - playerWidth
+			} else {
+				l = (floor(next/cellSize) + 1) * cellSize
+			}
+			vx = 0
+		} else {
+			l = next
+		}
+
+		onGround := false
+		if next := t + vy*leapStep; g.overlaps(l, next) {
+			if vy > 0 {
+				t = floor((next+playerHeight)/cellSize)*cellSize - playerHeight
+				onGround = true
+			} else {
+				t = (floor(next/cellSize) + 1) * cellSize
+			}
+			vy = 0
+		} else {
+			t = next
+		}
+
+		if step >= hold && onGround && vx == 0 {
+			return g.landing(l, t)
+		}
+	}
+	return spot{}, false
+}
+
+// overlaps reports if the player's box at l, t covers a solid cell.
+func (g *grid) overlaps(l, t float32) bool {
+	const edge = 0.001
+	col0, col1 := int(floor(l/cellSize)), int(floor((l+playerWidth-edge)/cellSize))
+	row0, row1 := int(floor(t/cellSize)), int(floor((t+playerHeight-edge)/cellSize))
+	return !g.free(col0, row0, col1-col0+1, row1-row0+1)
+}
+
+// landing is the spot the player is standing in when stopped at l, t, which is
+// rarely lined up with the cells so either one under it can be it.
+func (g *grid) landing(l, t float32) (spot, bool) {
+	row := int(floor((t+playerHeight)/cellSize+0.5)) - 1
+	near := int(floor(l/cellSize + 0.5))
+	for _, col := range []int{near, int(floor(l / cellSize)), int(floor(l/cellSize)) + 1} {
+		if at := (spot{col: col, row: row}); g.standing(at) {
+			return at, true
+		}
+	}
+	return spot{}, false
+}
@@ -0,0 +1,8 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="blocks" tilewidth="32" tileheight="32" tilecount="2" columns="2">
+ <tile id="0">
+  <properties>
+   <property name="indestructible" type="bool" value="true"/>
+  </properties>
+ </tile>
+</tileset>
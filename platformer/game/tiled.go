@@ -0,0 +1,512 @@
+package game
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// the top bits of a gid say how the tile is flipped, which doesn't matter to a
+// block
+const tiledFlipFlags uint32 = 0xF0000000
+
+// tiledMap is a map saved by Tiled in either of its formats, TMX (XML) or JSON,
+// with only what a level is built from.
+type tiledMap struct {
+	orientation           string
+	infinite              bool
+	width, height         int
+	tileWidth, tileHeight int
+	tilesets              []tiledTileset
+	layers                []tiledLayer
+}
+
+type tiledTileset struct {
+	firstGID uint32
+	tiles    map[uint32]properties
+}
+
+type tiledLayer struct {
+	kind             string
+	name             string
+	offsetX, offsetY float32
+	properties       properties
+	width, height    int
+	gids             []uint32
+	objects          []tiledObject
+	layers           []tiledLayer
+}
+
+type tiledObject struct {
+	name, kind          string
+	x, y, width, height float32
+	gid                 uint32
+	properties          properties
+}
+
+const (
+	tiledTileLayer   = "tilelayer"
+	tiledObjectGroup = "objectgroup"
+	tiledGroup       = "group"
+)
+
+// loadTiled reads a .tmx map as XML and anything else, like .json or .tmj, as
+// JSON. External tilesets are read relative to the map.
+func loadTiled(path string) (*tiledMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	if strings.EqualFold(filepath.Ext(path), ".tmx") {
+		return decodeTMX(data, dir)
+	}
+	return decodeTiledJSON(data, dir)
+}
+
+// tile returns the properties of the tile with the gid, nil if it has none.
+func (tiled *tiledMap) tile(gid uint32) properties {
+	var found *tiledTileset
+	for i := range tiled.tilesets {
+		tileset := &tiled.tilesets[i]
+		if tileset.firstGID <= gid && (found == nil || tileset.firstGID > found.firstGID) {
+			found = tileset
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return found.tiles[gid-found.firstGID]
+}
+
+type tmxMap struct {
+	Orientation string       `xml:"orientation,attr"`
+	Infinite    int          `xml:"infinite,attr"`
+	Width       int          `xml:"width,attr"`
+	Height      int          `xml:"height,attr"`
+	TileWidth   int          `xml:"tilewidth,attr"`
+	TileHeight  int          `xml:"tileheight,attr"`
+	Tilesets    []tmxTileset `xml:"tileset"`
+	// layers, object groups and groups in the order they are drawn
+	Layers []tmxLayer `xml:",any"`
+}
+
+type tmxTileset struct {
+	FirstGID uint32    `xml:"firstgid,attr"`
+	Source   string    `xml:"source,attr"`
+	Tiles    []tmxTile `xml:"tile"`
+}
+
+type tmxTile struct {
+	ID         uint32        `xml:"id,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+type tmxLayer struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	OffsetX    float32       `xml:"offsetx,attr"`
+	OffsetY    float32       `xml:"offsety,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Data       tmxData       `xml:"data"`
+	Objects    []tmxObject   `xml:"object"`
+	Layers     []tmxLayer    `xml:",any"`
+}
+
+type tmxData struct {
+	Encoding    string `xml:"encoding,attr"`
+	Compression string `xml:"compression,attr"`
+	Tiles       []struct {
+		GID uint32 `xml:"gid,attr"`
+	} `xml:"tile"`
+	Text string `xml:",chardata"`
+}
+
+type tmxObject struct {
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float32       `xml:"x,attr"`
+	Y          float32       `xml:"y,attr"`
+	Width      float32       `xml:"width,attr"`
+	Height     float32       `xml:"height,attr"`
+	GID        uint32        `xml:"gid,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	// multi line strings are saved as text instead of a value
+	Text string `xml:",chardata"`
+}
+
+func decodeTMX(data []byte, dir string) (*tiledMap, error) {
+	var tmx tmxMap
+	if err := xml.Unmarshal(data, &tmx); err != nil {
+		return nil, err
+	}
+	tiled := &tiledMap{
+		orientation: tmx.Orientation,
+		infinite:    tmx.Infinite != 0,
+		width:       tmx.Width,
+		height:      tmx.Height,
+		tileWidth:   tmx.TileWidth,
+		tileHeight:  tmx.TileHeight,
+	}
+	for _, tileset := range tmx.Tilesets {
+		if tileset.Source != "" {
+			source, err := loadTileset(filepath.Join(dir, tileset.Source))
+			if err != nil {
+				return nil, err
+			}
+			tileset.Tiles = source.Tiles
+		}
+		tiles := map[uint32]properties{}
+		for _, tile := range tileset.Tiles {
+			props, err := tmxProperties(tile.Properties)
+			if err != nil {
+				return nil, fmt.Errorf("tile %v: %v", tile.ID, err)
+			}
+			tiles[tile.ID] = props
+		}
+		tiled.tilesets = append(tiled.tilesets, tiledTileset{firstGID: tileset.FirstGID, tiles: tiles})
+	}
+	var err error
+	tiled.layers, err = tmxLayers(tmx.Layers)
+	return tiled, err
+}
+
+// loadTileset reads an external tileset, .tsx as XML and anything else as JSON.
+func loadTileset(path string) (tmxTileset, error) {
+	var tileset tmxTileset
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return tileset, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".tsx") {
+		err = xml.Unmarshal(data, &tileset)
+		return tileset, err
+	}
+	var external jsonTileset
+	if err := json.Unmarshal(data, &external); err != nil {
+		return tileset, fmt.Errorf("%v: %v", path, err)
+	}
+	for _, tile := range external.Tiles {
+		converted := tmxTile{ID: tile.ID}
+		for _, prop := range tile.Properties {
+			converted.Properties = append(converted.Properties, tmxProperty{Name: prop.Name, Type: prop.Type, Value: prop.value()})
+		}
+		tileset.Tiles = append(tileset.Tiles, converted)
+	}
+	return tileset, nil
+}
+
+func tmxLayers(elements []tmxLayer) ([]tiledLayer, error) {
+	layers := []tiledLayer{}
+	for _, element := range elements {
+		layer := tiledLayer{
+			name:    element.Name,
+			offsetX: element.OffsetX,
+			offsetY: element.OffsetY,
+			width:   element.Width,
+			height:  element.Height,
+		}
+		var err error
+		if layer.properties, err = tmxProperties(element.Properties); err != nil {
+			return nil, fmt.Errorf("layer %q: %v", layer.name, err)
+		}
+		switch element.XMLName.Local {
+		case "layer":
+			layer.kind = tiledTileLayer
+			layer.gids, err = tmxGIDs(element.Data)
+		case "objectgroup":
+			layer.kind = tiledObjectGroup
+			for _, object := range element.Objects {
+				converted := tiledObject{
+					name:   object.Name,
+					kind:   object.Type,
+					x:      object.X,
+					y:      object.Y,
+					width:  object.Width,
+					height: object.Height,
+					gid:    object.GID,
+				}
+				if converted.kind == "" {
+					converted.kind = object.Class
+				}
+				if converted.properties, err = tmxProperties(object.Properties); err != nil {
+					break
+				}
+				layer.objects = append(layer.objects, converted)
+			}
+		case "group":
+			layer.kind = tiledGroup
+			layer.layers, err = tmxLayers(element.Layers)
+		default:
+			// image layers and anything newer have nothing to build
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layer %q: %v", layer.name, err)
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+func tmxGIDs(data tmxData) ([]uint32, error) {
+	switch data.Encoding {
+	case "":
+		gids := make([]uint32, len(data.Tiles))
+		for i, tile := range data.Tiles {
+			gids[i] = tile.GID
+		}
+		return gids, nil
+	case "csv":
+		gids := []uint32{}
+		for _, field := range strings.Split(data.Text, ",") {
+			gid, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			gids = append(gids, uint32(gid))
+		}
+		return gids, nil
+	case "base64":
+		return base64GIDs(data.Text, data.Compression)
+	}
+	return nil, fmt.Errorf("unknown encoding %q", data.Encoding)
+}
+
+// base64GIDs decodes layer data saved as base64, each gid a little endian
+// 32 bit number, optionally compressed.
+func base64GIDs(text, compression string) ([]uint32, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "":
+	case "gzip":
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return nil, err
+		}
+	case "zlib":
+		if reader, err = zlib.NewReader(reader); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %q, save the map with gzip, zlib or none", compression)
+	}
+	if raw, err = io.ReadAll(reader); err != nil {
+		return nil, err
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("layer data is %v bytes, not a whole number of tiles", len(raw))
+	}
+	gids := make([]uint32, len(raw)/4)
+	for i := range gids {
+		gids[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	return gids, nil
+}
+
+func tmxProperties(list []tmxProperty) (properties, error) {
+	props := properties{}
+	for _, prop := range list {
+		value := prop.Value
+		if value == "" {
+			value = prop.Text
+		}
+		if err := props.set(prop.Name, prop.Type, value); err != nil {
+			return nil, err
+		}
+	}
+	return props, nil
+}
+
+type jsonMap struct {
+	Orientation string        `json:"orientation"`
+	Infinite    bool          `json:"infinite"`
+	Width       int           `json:"width"`
+	Height      int           `json:"height"`
+	TileWidth   int           `json:"tilewidth"`
+	TileHeight  int           `json:"tileheight"`
+	Tilesets    []jsonTileset `json:"tilesets"`
+	Layers      []jsonLayer   `json:"layers"`
+}
+
+type jsonTileset struct {
+	FirstGID uint32 `json:"firstgid"`
+	Source   string `json:"source"`
+	Tiles    []struct {
+		ID         uint32         `json:"id"`
+		Properties []jsonProperty `json:"properties"`
+	} `json:"tiles"`
+}
+
+type jsonLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	OffsetX     float32         `json:"offsetx"`
+	OffsetY     float32         `json:"offsety"`
+	Properties  []jsonProperty  `json:"properties"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Data        json.RawMessage `json:"data"`
+	Objects     []struct {
+		Name       string         `json:"name"`
+		Type       string         `json:"type"`
+		Class      string         `json:"class"`
+		X          float32        `json:"x"`
+		Y          float32        `json:"y"`
+		Width      float32        `json:"width"`
+		Height     float32        `json:"height"`
+		GID        uint32         `json:"gid"`
+		Properties []jsonProperty `json:"properties"`
+	} `json:"objects"`
+	Layers []jsonLayer `json:"layers"`
+}
+
+type jsonProperty struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// value is the property as it would be written in a TMX file. Numbers are
+// written out in full as fmt would put large ints in exponent form, which
+// doesn't parse as an int.
+func (prop jsonProperty) value() string {
+	switch value := prop.Value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprint(prop.Value)
+}
+
+func decodeTiledJSON(data []byte, dir string) (*tiledMap, error) {
+	var doc jsonMap
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	tiled := &tiledMap{
+		orientation: doc.Orientation,
+		infinite:    doc.Infinite,
+		width:       doc.Width,
+		height:      doc.Height,
+		tileWidth:   doc.TileWidth,
+		tileHeight:  doc.TileHeight,
+	}
+	for _, tileset := range doc.Tilesets {
+		tiles := map[uint32]properties{}
+		if tileset.Source != "" {
+			source, err := loadTileset(filepath.Join(dir, tileset.Source))
+			if err != nil {
+				return nil, err
+			}
+			for _, tile := range source.Tiles {
+				if tiles[tile.ID], err = tmxProperties(tile.Properties); err != nil {
+					return nil, fmt.Errorf("tile %v: %v", tile.ID, err)
+				}
+			}
+		}
+		for _, tile := range tileset.Tiles {
+			props, err := jsonProperties(tile.Properties)
+			if err != nil {
+				return nil, fmt.Errorf("tile %v: %v", tile.ID, err)
+			}
+			tiles[tile.ID] = props
+		}
+		tiled.tilesets = append(tiled.tilesets, tiledTileset{firstGID: tileset.FirstGID, tiles: tiles})
+	}
+	var err error
+	tiled.layers, err = jsonLayers(doc.Layers)
+	return tiled, err
+}
+
+func jsonLayers(list []jsonLayer) ([]tiledLayer, error) {
+	layers := []tiledLayer{}
+	for _, element := range list {
+		layer := tiledLayer{
+			kind:    element.Type,
+			name:    element.Name,
+			offsetX: element.OffsetX,
+			offsetY: element.OffsetY,
+			width:   element.Width,
+			height:  element.Height,
+		}
+		var err error
+		if layer.properties, err = jsonProperties(element.Properties); err != nil {
+			return nil, fmt.Errorf("layer %q: %v", layer.name, err)
+		}
+		switch element.Type {
+		case tiledTileLayer:
+			if element.Encoding == "base64" {
+				var text string
+				if err = json.Unmarshal(element.Data, &text); err == nil {
+					layer.gids, err = base64GIDs(text, element.Compression)
+				}
+			} else {
+				err = json.Unmarshal(element.Data, &layer.gids)
+			}
+		case tiledObjectGroup:
+			for _, object := range element.Objects {
+				converted := tiledObject{
+					name:   object.Name,
+					kind:   object.Type,
+					x:      object.X,
+					y:      object.Y,
+					width:  object.Width,
+					height: object.Height,
+					gid:    object.GID,
+				}
+				if converted.kind == "" {
+					converted.kind = object.Class
+				}
+				if converted.properties, err = jsonProperties(object.Properties); err != nil {
+					break
+				}
+				layer.objects = append(layer.objects, converted)
+			}
+		case tiledGroup:
+			layer.layers, err = jsonLayers(element.Layers)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layer %q: %v", layer.name, err)
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+func jsonProperties(list []jsonProperty) (properties, error) {
+	props := properties{}
+	for _, prop := range list {
+		if err := props.set(prop.Name, prop.Type, prop.value()); err != nil {
+			return nil, err
+		}
+	}
+	return props, nil
+}
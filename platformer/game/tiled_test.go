@@ -0,0 +1,100 @@
+package game
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadLevelEncodings(t *testing.T) {
+	// every fixture is the same map saved a different way: a block with the
+	// indestructible tile in each corner, the top right one flipped, and the
+	// player's start
+	want := []levelBlock{
+		{l: 0, t: 0, w: 32, h: 32, indestructible: true},
+		{l: 64, t: 0, w: 32, h: 32},
+		{l: 64, t: 32, w: 32, h: 32, indestructible: true},
+	}
+	for _, name := range []string{
+		"csv.tmx", "xml.tmx", "base64.tmx", "zlib.tmx", "gzip.tmx",
+		"array.json", "base64.json", "zlib.json", "gzip.json",
+	} {
+		level, err := LoadLevel(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if level.width != 96 || level.height != 64 {
+			t.Errorf("%v: %vx%v", name, level.width, level.height)
+		}
+		if !reflect.DeepEqual(level.blocks, want) {
+			t.Errorf("%v: blocks %+v", name, level.blocks)
+		}
+		if len(level.objects) != 1 || level.objects[0].kind != "player" || level.objects[0].l != 16 {
+			t.Errorf("%v: objects %+v", name, level.objects)
+		}
+	}
+}
+
+func TestLoadLevelObjects(t *testing.T) {
+	level, err := LoadLevel("testdata/objects.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(level.objects) != 3 {
+		t.Fatalf("objects %+v", level.objects)
+	}
+	player, wall, sentry := level.objects[0], level.objects[1], level.objects[2]
+	if player.l != 11 || player.t != 7 {
+		t.Errorf("the player is at %v, %v rather than offset by its group", player.l, player.t)
+	}
+	if wall.kind != "block" || !wall.properties.bool("indestructible", false) || wall.properties["hits"] != "1000000" {
+		t.Errorf("the wall should take its class as its type and inherit its group's properties, got %+v", wall)
+	}
+	// tile objects hang from their bottom left and take the tile's properties
+	if sentry.t != 5 || !sentry.properties.bool("indestructible", false) || sentry.properties.float("fireCoolDown", 0) != 2.5 {
+		t.Errorf("sentry %+v", sentry)
+	}
+}
+
+func TestLoadLevelErrors(t *testing.T) {
+	for name, want := range map[string]string{
+		"isometric.tmx":       "isometric maps are not supported",
+		"infinite.tmx":        "infinite maps are not supported",
+		"no-player.tmx":       "needs one player start, found 0",
+		"unknown-type.tmx":    `unknown type "dragon"`,
+		"bad-property.tmx":    `"fast" is not a valid float`,
+		"short-data.tmx":      "has 5 tiles but is 3x2",
+		"missing-tileset.tmx": "missing.tsx",
+		"zstd.tmx":            `unsupported compression "zstd"`,
+	} {
+		path := filepath.Join("testdata", "bad", name)
+		if _, err := LoadLevel(path); err == nil || !strings.Contains(err.Error(), want) || !strings.Contains(err.Error(), path) {
+			t.Errorf("%v: expected an error about %q, got %v", name, want, err)
+		}
+	}
+}
+
+func TestSampleLevel(t *testing.T) {
+	level, err := LoadLevel("../assets/level.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMap(level, nil)
+	if m.Player == nil || m.Player.l != 64 || m.Player.t != 860 {
+		t.Fatalf("player %+v", m.Player)
+	}
+	guardians, sentries := 0, 0
+	for _, object := range m.objects {
+		if guardian, ok := object.(*Guardian); ok {
+			guardians++
+			if guardian.activeRadius == 700 && guardian.aimDuration == 0.75 && guardian.fireCoolDown == 0.75 {
+				sentries++
+			}
+		}
+	}
+	if guardians != 5 || sentries != 1 {
+		t.Errorf("%v guardians, %v with the sentry's properties", guardians, sentries)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"runtime"
 
@@ -18,9 +19,12 @@ var (
 	height  float32 = 2000
 	camera  *lense.Camera
 	gameMap *game.Map
+	level   = flag.String("level", "", "play a level made in Tiled, saved as .tmx or .json, instead of a random one")
+	seed    = flag.String("seed", "", "generate the first level from this seed instead of a random one")
 )
 
 func main() {
+	flag.Parse()
 	amore.OnLoad = onLoad
 	amore.Start(update, draw)
 }
@@ -28,7 +32,26 @@ func main() {
 func onLoad() {
 	keyboard.OnKeyUp = keypress
 	camera = lense.New()
-	gameMap = game.NewMap(width, height, camera)
+	gameMap = game.NewMap(newLevel(), camera)
+}
+
+// newLevel is the level asked for with -level, or else one generated from a
+// random seed. -seed only picks the first generated level so that enter still
+// moves on to new ones.
+func newLevel() *game.Level {
+	if *level != "" {
+		loaded, err := game.LoadLevel(*level)
+		if err == nil {
+			return loaded
+		}
+		fmt.Println("could not load level:", err)
+	}
+	if *seed != "" {
+		first := *seed
+		*seed = ""
+		return game.GenerateLevel(first, width, height)
+	}
+	return game.GenerateLevel(game.RandomSeed(), width, height)
 }
 
 func update(dt float32) {
@@ -45,6 +68,9 @@ func draw() {
 	stats := runtime.MemStats{}
 	runtime.ReadMemStats(&stats)
 	gfx.Printf(fmt.Sprintf("fps: %v, mem: %vKB", timer.GetFPS(), stats.HeapAlloc/1000000), 200, gfx.AlignRight, w-200, h-40)
+	if seed := gameMap.Seed(); seed != "" {
+		gfx.Print(fmt.Sprintf("seed: %v", seed), 20, h-40)
+	}
 }
 
 func keypress(key keyboard.Key) {
@@ -54,6 +80,6 @@ func keypress(key keyboard.Key) {
 	case keyboard.KeyTab:
 		gameMap.ToggleDebug()
 	case keyboard.KeyReturn:
-		gameMap.Reset()
+		gameMap.Play(newLevel())
 	}
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/tanema/amore/gfx"
 	"github.com/tanema/amore/keyboard"
 	"github.com/tanema/amore/window"
+
+	"github.com/tanema/amore-examples/highscore"
 )
 
 const (
@@ -53,12 +55,15 @@ var (
 	}
 
 	scoreLabel *gfx.Text
+	scores     *highscore.Table
+	initials   *highscore.Initials
 	blip, _    = audio.NewSource("audio/blip.wav", true)
 	bomb, _    = audio.NewSource("../test-all/assets/audio/bomb.wav", true)
 )
 
 func main() {
 	window.SetMouseVisible(false)
+	keyboard.OnKeyUp = keyup
 	amore.OnLoad = load
 	amore.Start(update, draw)
 }
@@ -70,18 +75,42 @@ func load() {
 		gfx.GetFont(),
 		fmt.Sprintf("%v : %v", enemy.score, player.score),
 	)
+	scores = highscore.Load("pong", false)
+}
+
+// keyup types initials for a new high score, the points the player scored in
+// the match are what goes in the table.
+func keyup(key keyboard.Key) {
+	if initials == nil {
+		return
+	}
+	switch {
+	case key >= keyboard.KeyA && key <= keyboard.KeyZ:
+		initials.Type('A' + rune(key-keyboard.KeyA))
+	case key == keyboard.KeyBackspace:
+		initials.Erase()
+	case key == keyboard.KeyReturn:
+		scores.Add(initials.Value(), player.score)
+		if err := scores.Save(); err != nil {
+			fmt.Println("could not save high scores:", err)
+		}
+		initials = nil
+	}
 }
 
 func update(dt float32) {
 	if keyboard.IsDown(keyboard.KeyEscape) {
 		amore.Quit()
 	}
-	if keyboard.IsDown(keyboard.KeyReturn) {
+	if keyboard.IsDown(keyboard.KeyReturn) && initials == nil {
 		reset()
 	}
 
-	if player.score >= 10 || enemy.score >= 10 {
+	if !gameOver && (player.score >= 10 || enemy.score >= 10) {
 		gameOver = true
+		if player.score > 0 && scores.Qualifies(player.score) {
+			initials = &highscore.Initials{}
+		}
 	}
 
 	if gameOver {
@@ -160,6 +189,15 @@ func draw() {
 		gfx.Print(gameOverString, leftAlign+10, 215)
 		gfx.Print(wonlost, leftAlign+10, 230)
 		gfx.Print(pressEnter, leftAlign+10, 245)
+
+		gfx.SetColor(255, 255, 255, 255)
+		if initials != nil {
+			gfx.Print("New high score! Enter your initials: "+initials.String(), leftAlign+10, 290)
+		} else {
+			for i, entry := range scores.Entries {
+				gfx.Print(fmt.Sprintf("%2v. %-3v %3v", i+1, entry.Initials, entry.Score), leftAlign+10, 290+float32(i)*15)
+			}
+		}
 	}
 }
 
@@ -69,6 +69,7 @@ func New() {
 	initSpriteSheets()
 	resetRoad()
 	player = newPlayer(cameraHeight * cameraDepth)
+	resetLaps()
 }
 
 func Update(dt float32) {
@@ -80,6 +81,7 @@ func Update(dt float32) {
 	skyOffset = increase(skyOffset, skySpeed*player.segment.curve*(player.position-startPosition)/segmentLength, 1)
 	hillOffset = increase(hillOffset, hillSpeed*player.segment.curve*(player.position-startPosition)/segmentLength, 1)
 	treeOffset = increase(treeOffset, treeSpeed*player.segment.curve*(player.position-startPosition)/segmentLength, 1)
+	updateLaps(dt, startPosition)
 }
 
 func Draw() {
@@ -148,6 +150,7 @@ func Draw() {
 	}
 
 	player.draw()
+	drawLaps()
 }
 
 func findSegment(z float32) *Segment {
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/tanema/amore/gfx"
+
+	"github.com/tanema/amore-examples/highscore"
+)
+
+const raceLaps = 3 // laps in a race, the best of which can make the lap times
+
+var (
+	lap       int                 // the lap being driven, from 1 to raceLaps
+	lapTime   float32             // time spent on the current lap
+	lastLap   float32             // time of the last finished lap
+	bestLap   float32             // fastest lap of the race so far
+	recordLap int                 // the lap time in milliseconds waiting for initials
+	scores    *highscore.Table    // best lap times, fastest first
+	initials  *highscore.Initials // initials being typed for a record lap
+)
+
+func resetLaps() {
+	lap, lapTime, lastLap, bestLap = 1, 0, 0, 0
+	scores = highscore.Load("racer", true)
+}
+
+// updateLaps times the current lap. A lap is finished when the position loops
+// back around to the start of the track, and once the last lap of a race is
+// finished its best lap is checked against the lap times and the next race
+// starts.
+func updateLaps(dt, startPosition float32) {
+	lapTime += dt
+	if player.position >= startPosition-trackLength/2 {
+		return
+	}
+	lastLap, lapTime = lapTime, 0
+	if bestLap == 0 || lastLap < bestLap {
+		bestLap = lastLap
+	}
+	if lap < raceLaps {
+		lap++
+		return
+	}
+	if best := int(bestLap * 1000); initials == nil && scores.Qualifies(best) {
+		recordLap = best
+		initials = &highscore.Initials{}
+	}
+	lap, bestLap = 1, 0
+}
+
+// Initials returns the initials being entered for a record lap, or nil if the
+// last race didn't make the lap times.
+func Initials() *highscore.Initials {
+	return initials
+}
+
+// SubmitInitials adds the record lap to the lap times under the initials that
+// were entered.
+func SubmitInitials() {
+	if initials == nil {
+		return
+	}
+	scores.Add(initials.Value(), recordLap)
+	if err := scores.Save(); err != nil {
+		fmt.Println("could not save lap times:", err)
+	}
+	initials = nil
+}
+
+func drawLaps() {
+	gfx.SetColor(255, 255, 255, 255)
+	gfx.Print(fmt.Sprintf("lap %v/%v: %.2f last: %.2f", lap, raceLaps, lapTime, lastLap), 0, 0)
+	if initials != nil {
+		gfx.Print(fmt.Sprintf("Lap record %.2f! Enter your initials: %v", float32(recordLap)/1000, initials), 0, 15)
+		return
+	}
+	for i, entry := range scores.Entries {
+		gfx.Print(fmt.Sprintf("%2v. %-3v %.2f", i+1, entry.Initials, float32(entry.Score)/1000), 0, 15+float32(i)*15)
+	}
+}
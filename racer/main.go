@@ -8,10 +8,31 @@ import (
 )
 
 func main() {
-	amore.OnLoad = game.New
+	amore.OnLoad = load
 	amore.Start(update, draw)
 }
 
+func load() {
+	game.New()
+	keyboard.OnKeyUp = keyup
+}
+
+// keyup types initials for a record lap at the end of a race.
+func keyup(key keyboard.Key) {
+	initials := game.Initials()
+	if initials == nil {
+		return
+	}
+	switch {
+	case key >= keyboard.KeyA && key <= keyboard.KeyZ:
+		initials.Type('A' + rune(key-keyboard.KeyA))
+	case key == keyboard.KeyBackspace:
+		initials.Erase()
+	case key == keyboard.KeyReturn:
+		game.SubmitInitials()
+	}
+}
+
 func update(deltaTime float32) {
 	if keyboard.IsDown(keyboard.KeyEscape) {
 		amore.Quit()